@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type tokenFile struct {
+	Tokens []Token `json:"tokens"`
+}
+
+// TokenStore keeps tokens in a JSON file. The file is re-read whenever it
+// changes, so tokens created or revoked from the command line take effect
+// in a running server.
+type TokenStore struct {
+	path string
+
+	mu      sync.Mutex
+	tokens  []Token
+	modTime time.Time
+	size    int64
+	loaded  bool
+}
+
+func NewTokenStore(path string) *TokenStore {
+	return &TokenStore{path: path}
+}
+
+// DefaultPath returns the token file under the user's config directory.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "ai-sensors", "tokens.json")
+}
+
+func (s *TokenStore) Path() string {
+	return s.path
+}
+
+// refresh must be called with s.mu held.
+func (s *TokenStore) refresh() error {
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.tokens, s.loaded = nil, true
+		s.modTime, s.size = time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if s.loaded && info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	var file tokenFile
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &file); err != nil {
+			return err
+		}
+	}
+	s.tokens, s.loaded = file.Tokens, true
+	s.modTime, s.size = info.ModTime(), info.Size()
+	return nil
+}
+
+// save must be called with s.mu held. The file is replaced atomically and
+// is readable by its owner only.
+func (s *TokenStore) save(tokens []Token) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(tokenFile{Tokens: tokens}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".tokens-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	s.loaded = false
+	return s.refresh()
+}
+
+// Create adds a token and returns it with its secret, which is not stored
+// and cannot be recovered later.
+func (s *TokenStore) Create(name string, scopes []Scope) (Token, string, error) {
+	if name == "" {
+		return Token{}, "", ErrEmptyName
+	}
+	if len(scopes) == 0 {
+		return Token{}, "", ErrNoScopes
+	}
+	for _, scope := range scopes {
+		if !slices.Contains(AllScopes, scope) {
+			return Token{}, "", ErrInvalidScope
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return Token{}, "", err
+	}
+	if slices.ContainsFunc(s.tokens, func(t Token) bool { return t.Name == name }) {
+		return Token{}, "", ErrDuplicateName
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Token{}, "", err
+	}
+	token := Token{
+		ID:        uuid.Must(uuid.NewV7()),
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+		Hash:      hashSecret(secret),
+	}
+	if err := s.save(append(slices.Clone(s.tokens), token)); err != nil {
+		return Token{}, "", err
+	}
+	return token, secret, nil
+}
+
+func (s *TokenStore) List() ([]Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return slices.Clone(s.tokens), nil
+}
+
+// Revoke deletes the token with the given name or ID.
+func (s *TokenStore) Revoke(nameOrID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	i := slices.IndexFunc(s.tokens, func(t Token) bool {
+		return t.Name == nameOrID || t.ID.String() == nameOrID
+	})
+	if i < 0 {
+		return ErrTokenNotFound
+	}
+	return s.save(slices.Delete(slices.Clone(s.tokens), i, i+1))
+}
+
+// Enabled reports whether any token exists. Without tokens the server runs
+// unauthenticated.
+func (s *TokenStore) Enabled() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return false, err
+	}
+	return len(s.tokens) > 0, nil
+}
+
+// Authenticate returns the token whose secret is given.
+func (s *TokenStore) Authenticate(secret string) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return Token{}, err
+	}
+	for _, t := range s.tokens {
+		if t.matches(secret) {
+			return t, nil
+		}
+	}
+	return Token{}, ErrTokenNotFound
+}
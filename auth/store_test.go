@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *TokenStore {
+	t.Helper()
+	return NewTokenStore(filepath.Join(t.TempDir(), "config", "tokens.json"))
+}
+
+func TestTokenStore_CreateAndAuthenticate(t *testing.T) {
+	s := newTestStore(t)
+
+	enabled, err := s.Enabled()
+	require.NoError(t, err)
+	assert.False(t, enabled)
+
+	token, secret, err := s.Create("ci", []Scope{ScopeRead, ScopeControl})
+	require.NoError(t, err)
+	assert.Contains(t, secret, secretPrefix)
+	assert.NotContains(t, token.Hash, secret)
+
+	enabled, err = s.Enabled()
+	require.NoError(t, err)
+	assert.True(t, enabled)
+
+	got, err := s.Authenticate(secret)
+	require.NoError(t, err)
+	assert.Equal(t, token.ID, got.ID)
+	assert.True(t, got.Allows(ScopeControl))
+	assert.False(t, got.Allows(ScopeDefine))
+
+	_, err = s.Authenticate(secret + "x")
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+}
+
+func TestTokenStore_FileIsPrivateAndHoldsNoSecret(t *testing.T) {
+	s := newTestStore(t)
+
+	_, secret, err := s.Create("ci", []Scope{ScopeRead})
+	require.NoError(t, err)
+
+	info, err := os.Stat(s.Path())
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	data, err := os.ReadFile(s.Path())
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), secret)
+}
+
+func TestTokenStore_SeesChangesFromOtherProcesses(t *testing.T) {
+	s := newTestStore(t)
+	other := NewTokenStore(s.Path())
+
+	_, secret, err := other.Create("cli", []Scope{ScopeRead})
+	require.NoError(t, err)
+
+	_, err = s.Authenticate(secret)
+	require.NoError(t, err)
+
+	require.NoError(t, other.Revoke("cli"))
+
+	_, err = s.Authenticate(secret)
+	assert.ErrorIs(t, err, ErrTokenNotFound)
+}
+
+func TestTokenStore_CreateValidates(t *testing.T) {
+	s := newTestStore(t)
+
+	_, _, err := s.Create("", []Scope{ScopeRead})
+	assert.ErrorIs(t, err, ErrEmptyName)
+
+	_, _, err = s.Create("ci", nil)
+	assert.ErrorIs(t, err, ErrNoScopes)
+
+	_, _, err = s.Create("ci", []Scope{"admin"})
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	_, _, err = s.Create("ci", []Scope{ScopeRead})
+	require.NoError(t, err)
+	_, _, err = s.Create("ci", []Scope{ScopeRead})
+	assert.ErrorIs(t, err, ErrDuplicateName)
+}
+
+func TestTokenStore_RevokeByID(t *testing.T) {
+	s := newTestStore(t)
+
+	token, _, err := s.Create("ci", []Scope{ScopeRead})
+	require.NoError(t, err)
+
+	require.NoError(t, s.Revoke(token.ID.String()))
+	assert.ErrorIs(t, s.Revoke(token.ID.String()), ErrTokenNotFound)
+
+	tokens, err := s.List()
+	require.NoError(t, err)
+	assert.Empty(t, tokens)
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("read, control,read")
+	require.NoError(t, err)
+	assert.Equal(t, []Scope{ScopeRead, ScopeControl}, scopes)
+
+	_, err = ParseScopes("read,admin")
+	assert.ErrorIs(t, err, ErrInvalidScope)
+
+	_, err = ParseScopes(" , ")
+	assert.ErrorIs(t, err, ErrNoScopes)
+}
@@ -0,0 +1,95 @@
+// Package auth manages the API tokens that grant access to the server.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEmptyName     = errors.New("token name cannot be empty")
+	ErrDuplicateName = errors.New("token name already in use")
+	ErrNoScopes      = errors.New("token needs at least one scope")
+	ErrInvalidScope  = errors.New("invalid scope")
+	ErrTokenNotFound = errors.New("token not found")
+)
+
+type Scope string
+
+const (
+	// ScopeRead allows reading command definitions, status and output.
+	ScopeRead Scope = "read"
+	// ScopeControl allows starting, stopping and running commands.
+	ScopeControl Scope = "control"
+	// ScopeDefine allows creating and deleting commands, which amounts to
+	// running arbitrary shell commands on the host.
+	ScopeDefine Scope = "define"
+)
+
+var AllScopes = []Scope{ScopeRead, ScopeControl, ScopeDefine}
+
+// ParseScopes parses a comma-separated scope list such as "read,control".
+func ParseScopes(s string) ([]Scope, error) {
+	var scopes []Scope
+	for _, part := range strings.Split(s, ",") {
+		scope := Scope(strings.TrimSpace(part))
+		if scope == "" {
+			continue
+		}
+		if !slices.Contains(AllScopes, scope) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidScope, scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	if len(scopes) == 0 {
+		return nil, ErrNoScopes
+	}
+	return scopes, nil
+}
+
+// Token is a stored API token. Only a hash of the secret is kept; the
+// secret itself is shown once, when the token is created.
+type Token struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	Scopes    []Scope   `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+	Hash      string    `json:"hash,omitempty"`
+}
+
+func (t Token) Allows(scope Scope) bool {
+	return slices.Contains(t.Scopes, scope)
+}
+
+// secretPrefix marks token secrets so they are recognizable in config files
+// and by secret scanners.
+const secretPrefix = "ais_"
+
+func newSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (t Token) matches(secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hashSecret(secret))) == 1
+}
@@ -0,0 +1,74 @@
+package buffer
+
+// Follow returns up to the last n complete lines and a channel receiving
+// every line completed after them, so a reader sees the output without
+// gaps or repeats. Collapsed repeats are delivered individually. The
+// channel is closed when stop is called, after Close, or as soon as the
+// reader falls more than size lines behind.
+func (rb *RingBuffer) Follow(n, size int) (lines []string, ch <-chan string, stop func()) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	n = min(max(n, 0), rb.count)
+	lines = make([]string, 0, n)
+	start := (rb.head - n + rb.capacity) % rb.capacity
+	for i := range n {
+		lines = append(lines, rb.entries[(start+i)%rb.capacity].Text)
+	}
+
+	c := make(chan string, max(size, 1))
+	if rb.closed {
+		close(c)
+		return lines, c, func() {}
+	}
+	if rb.followers == nil {
+		rb.followers = make(map[chan string]struct{})
+	}
+	rb.followers[c] = struct{}{}
+
+	stop = func() {
+		rb.mu.Lock()
+		defer rb.mu.Unlock()
+		if _, ok := rb.followers[c]; ok {
+			delete(rb.followers, c)
+			close(c)
+		}
+	}
+	return lines, c, stop
+}
+
+// Close marks the end of the output: the incomplete trailing line, if any,
+// is delivered to followers and their channels are closed. The buffer
+// stays readable.
+func (rb *RingBuffer) Close() {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+
+	if rb.closed {
+		return
+	}
+	rb.closed = true
+	if rb.pending != "" {
+		rb.notify([]string{rb.pending})
+	}
+	for c := range rb.followers {
+		delete(rb.followers, c)
+		close(c)
+	}
+}
+
+// notify must be called with rb.mu held.
+func (rb *RingBuffer) notify(lines []string) {
+	for c := range rb.followers {
+		for _, line := range lines {
+			select {
+			case c <- line:
+				continue
+			default:
+			}
+			delete(rb.followers, c)
+			close(c)
+			break
+		}
+	}
+}
@@ -4,27 +4,71 @@ import (
 	"errors"
 	"strings"
 	"sync"
+	"time"
 )
 
 var ErrInvalidCapacity = errors.New("capacity must be greater than 0")
 
+// Entry is one slot of the buffer. Without dedup every entry holds a single
+// line; with dedup, consecutive identical lines share an entry whose Count
+// records how often the line was written and whose First and Last record
+// when.
+type Entry struct {
+	Text  string    `json:"text"`
+	Count int       `json:"count"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// Stats counts the traffic through a buffer.
+type Stats struct {
+	// Lines is the number of complete lines written.
+	Lines uint64 `json:"lines"`
+	// Dropped is the number of lines evicted to make room for newer ones.
+	Dropped uint64 `json:"dropped"`
+	// Bytes is the size of the text currently held, excluding repeats
+	// collapsed by dedup.
+	Bytes int `json:"bytes"`
+}
+
 type RingBuffer struct {
-	mu       sync.RWMutex
-	lines    []string
-	capacity int
-	head     int
-	count    int
-	pending  string
+	mu        sync.RWMutex
+	entries   []Entry
+	capacity  int
+	head      int
+	count     int
+	stats     Stats
+	dedup     bool
+	pending   string
+	pendingAt time.Time
+	observers []func(line string)
+	followers map[chan string]struct{}
+	closed    bool
 }
 
-func New(capacity int) (*RingBuffer, error) {
+type Option func(*RingBuffer)
+
+// WithDedup collapses consecutive identical lines into a single entry, so
+// a process repeating itself cannot flush the rest of the output out of
+// the ring.
+func WithDedup() Option {
+	return func(rb *RingBuffer) {
+		rb.dedup = true
+	}
+}
+
+func New(capacity int, opts ...Option) (*RingBuffer, error) {
 	if capacity <= 0 {
 		return nil, ErrInvalidCapacity
 	}
-	return &RingBuffer{
-		lines:    make([]string, capacity),
+	rb := &RingBuffer{
+		entries:  make([]Entry, capacity),
 		capacity: capacity,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(rb)
+	}
+	return rb, nil
 }
 
 func (rb *RingBuffer) Write(p []byte) (n int, err error) {
@@ -32,47 +76,114 @@ func (rb *RingBuffer) Write(p []byte) (n int, err error) {
 		return 0, nil
 	}
 
+	now := time.Now()
 	rb.mu.Lock()
-	defer rb.mu.Unlock()
 
 	data := rb.pending + string(p)
+	first := rb.pendingAt
+	if rb.pending == "" {
+		first = now
+	}
 	rb.pending = ""
 
 	parts := strings.Split(data, "\n")
+	completed := make([]string, 0, len(parts)-1)
 
 	for i := 0; i < len(parts)-1; i++ {
 		line := strings.TrimSuffix(parts[i], "\r")
-		rb.addLine(line)
+		rb.addLine(line, first, now)
+		completed = append(completed, line)
+		first = now
 	}
 
 	lastPart := parts[len(parts)-1]
 	if lastPart != "" {
 		rb.pending = lastPart
+		rb.pendingAt = first
+	}
+
+	rb.notify(completed)
+	observers := rb.observers
+	rb.mu.Unlock()
+
+	for _, line := range completed {
+		for _, fn := range observers {
+			fn(line)
+		}
 	}
 
 	return len(p), nil
 }
 
-func (rb *RingBuffer) addLine(line string) {
-	rb.lines[rb.head] = line
+// OnLine registers fn to be called with every line completed by subsequent
+// writes. Observers run on the writing goroutine, after the buffer lock is
+// released, and see lines in the order they were written as long as writes
+// are not concurrent.
+func (rb *RingBuffer) OnLine(fn func(line string)) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.observers = append(rb.observers[:len(rb.observers):len(rb.observers)], fn)
+}
+
+func (rb *RingBuffer) addLine(line string, first, last time.Time) {
+	rb.stats.Lines++
+	if rb.dedup && rb.count > 0 {
+		prev := &rb.entries[(rb.head-1+rb.capacity)%rb.capacity]
+		if prev.Text == line {
+			prev.Count++
+			prev.Last = last
+			return
+		}
+	}
+
+	if rb.count == rb.capacity {
+		evicted := rb.entries[rb.head]
+		rb.stats.Dropped += uint64(evicted.Count)
+		rb.stats.Bytes -= len(evicted.Text)
+	}
+	rb.entries[rb.head] = Entry{Text: line, Count: 1, First: first, Last: last}
+	rb.stats.Bytes += len(line)
 	rb.head = (rb.head + 1) % rb.capacity
 	if rb.count < rb.capacity {
 		rb.count++
 	}
 }
 
+// Stats returns the buffer's counters.
+func (rb *RingBuffer) Stats() Stats {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	return rb.stats
+}
+
+// Lines returns the text of every entry, oldest first, followed by the
+// incomplete trailing line if there is one. Collapsed repeats appear once.
 func (rb *RingBuffer) Lines() []string {
-	return rb.getLines(rb.capacity + 1)
+	return texts(rb.getEntries(rb.capacity + 1))
 }
 
 func (rb *RingBuffer) LastN(n int) []string {
 	if n <= 0 {
 		return []string{}
 	}
-	return rb.getLines(n)
+	return texts(rb.getEntries(n))
+}
+
+// Entries returns every entry with its repeat count and timestamps, in the
+// same order as Lines.
+func (rb *RingBuffer) Entries() []Entry {
+	return rb.getEntries(rb.capacity + 1)
+}
+
+func texts(entries []Entry) []string {
+	result := make([]string, len(entries))
+	for i, e := range entries {
+		result[i] = e.Text
+	}
+	return result
 }
 
-func (rb *RingBuffer) getLines(n int) []string {
+func (rb *RingBuffer) getEntries(n int) []Entry {
 	rb.mu.RLock()
 	defer rb.mu.RUnlock()
 	hasPending := rb.pending != ""
@@ -85,7 +196,7 @@ func (rb *RingBuffer) getLines(n int) []string {
 		n = available
 	}
 	if n <= 0 {
-		return []string{}
+		return []Entry{}
 	}
 
 	fromBuffer := n
@@ -93,17 +204,17 @@ func (rb *RingBuffer) getLines(n int) []string {
 		fromBuffer--
 	}
 
-	result := make([]string, 0, n)
+	result := make([]Entry, 0, n)
 
 	if fromBuffer > 0 {
 		start := (rb.head - fromBuffer + rb.capacity) % rb.capacity
 		for i := range fromBuffer {
-			result = append(result, rb.lines[(start+i)%rb.capacity])
+			result = append(result, rb.entries[(start+i)%rb.capacity])
 		}
 	}
 
 	if hasPending {
-		result = append(result, rb.pending)
+		result = append(result, Entry{Text: rb.pending, Count: 1, First: rb.pendingAt, Last: rb.pendingAt})
 	}
 
 	return result
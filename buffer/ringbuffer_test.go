@@ -197,3 +197,163 @@ func TestRingBuffer_LastNNegative(t *testing.T) {
 
 	assert.Equal(t, []string{}, result)
 }
+
+func TestRingBuffer_OnLineNotifiesCompletedLines(t *testing.T) {
+	rb, err := New(2)
+	require.NoError(t, err)
+
+	var first, second []string
+	rb.OnLine(func(line string) { first = append(first, line) })
+	rb.OnLine(func(line string) { second = append(second, line) })
+
+	_, _ = rb.Write([]byte("a\nb"))
+	_, _ = rb.Write([]byte("c\r\nd\ne\n"))
+
+	assert.Equal(t, []string{"a", "bc", "d", "e"}, first)
+	assert.Equal(t, first, second)
+	assert.Equal(t, []string{"d", "e"}, rb.Lines())
+}
+
+func TestRingBuffer_DedupCollapsesConsecutiveRepeats(t *testing.T) {
+	rb, err := New(3, WithDedup())
+	require.NoError(t, err)
+
+	_, _ = rb.Write([]byte("start\n"))
+	for range 100 {
+		_, _ = rb.Write([]byte("waiting for changes...\n"))
+	}
+	_, _ = rb.Write([]byte("rebuilt\nwaiting for changes...\n"))
+
+	assert.Equal(t, []string{"waiting for changes...", "rebuilt", "waiting for changes..."}, rb.Lines())
+
+	entries := rb.Entries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, 100, entries[0].Count)
+	assert.False(t, entries[0].Last.Before(entries[0].First))
+	assert.Equal(t, 1, entries[1].Count)
+	assert.Equal(t, 1, entries[2].Count)
+}
+
+func TestRingBuffer_DedupKeepsOlderLinesFromBeingFlushed(t *testing.T) {
+	rb, err := New(2, WithDedup())
+	require.NoError(t, err)
+
+	_, _ = rb.Write([]byte("listening on :8080\n"))
+	for range 50 {
+		_, _ = rb.Write([]byte("poll\n"))
+	}
+
+	assert.Equal(t, []string{"listening on :8080", "poll"}, rb.Lines())
+}
+
+func TestRingBuffer_DedupJoinsPartialWrites(t *testing.T) {
+	rb, err := New(5, WithDedup())
+	require.NoError(t, err)
+
+	_, _ = rb.Write([]byte("tick\nti"))
+	_, _ = rb.Write([]byte("ck\n"))
+
+	entries := rb.Entries()
+	require.Len(t, entries, 1)
+	assert.Equal(t, "tick", entries[0].Text)
+	assert.Equal(t, 2, entries[0].Count)
+}
+
+func TestRingBuffer_WithoutDedupKeepsRepeats(t *testing.T) {
+	rb, err := New(5)
+	require.NoError(t, err)
+
+	_, _ = rb.Write([]byte("x\nx\nx\n"))
+
+	assert.Equal(t, []string{"x", "x", "x"}, rb.Lines())
+	for _, e := range rb.Entries() {
+		assert.Equal(t, 1, e.Count)
+		assert.False(t, e.First.IsZero())
+	}
+}
+
+func TestRingBuffer_EntriesIncludePending(t *testing.T) {
+	rb, err := New(5)
+	require.NoError(t, err)
+
+	_, _ = rb.Write([]byte("done\npartial"))
+
+	entries := rb.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, "partial", entries[1].Text)
+	assert.Equal(t, 1, entries[1].Count)
+}
+
+func TestRingBuffer_Stats(t *testing.T) {
+	rb, err := New(3)
+	require.NoError(t, err)
+
+	_, _ = rb.Write([]byte("one\ntwo\nthree\nfour\nfive\npartial"))
+
+	stats := rb.Stats()
+	assert.Equal(t, uint64(5), stats.Lines)
+	assert.Equal(t, uint64(2), stats.Dropped)
+	assert.Equal(t, len("three")+len("four")+len("five"), stats.Bytes)
+}
+
+func TestRingBuffer_StatsWithDedup(t *testing.T) {
+	rb, err := New(2, WithDedup())
+	require.NoError(t, err)
+
+	_, _ = rb.Write([]byte("tick\ntick\ntick\nboom\nafter\n"))
+
+	stats := rb.Stats()
+	assert.Equal(t, uint64(5), stats.Lines)
+	assert.Equal(t, uint64(3), stats.Dropped, "evicting a collapsed entry drops every repeat")
+	assert.Equal(t, len("boom")+len("after"), stats.Bytes)
+}
+
+func TestRingBuffer_Follow(t *testing.T) {
+	rb, err := New(10)
+	require.NoError(t, err)
+	_, _ = rb.Write([]byte("one\ntwo\nthree\npart"))
+
+	lines, ch, stop := rb.Follow(2, 10)
+	defer stop()
+	assert.Equal(t, []string{"two", "three"}, lines, "the incomplete line is not part of the backlog")
+
+	_, _ = rb.Write([]byte("ial\nfour\n"))
+	assert.Equal(t, "partial", <-ch)
+	assert.Equal(t, "four", <-ch)
+
+	_, _ = rb.Write([]byte("tail"))
+	rb.Close()
+	assert.Equal(t, "tail", <-ch, "Close delivers the trailing line")
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	lines, ch, _ = rb.Follow(1, 10)
+	assert.Equal(t, []string{"four"}, lines)
+	_, ok = <-ch
+	assert.False(t, ok, "following a closed buffer ends immediately")
+}
+
+func TestRingBuffer_FollowDropsSlowReader(t *testing.T) {
+	rb, err := New(10)
+	require.NoError(t, err)
+
+	_, ch, stop := rb.Follow(0, 1)
+	_, _ = rb.Write([]byte("a\nb\n"))
+
+	assert.Equal(t, "a", <-ch)
+	_, ok := <-ch
+	assert.False(t, ok)
+	stop()
+}
+
+func TestRingBuffer_FollowStop(t *testing.T) {
+	rb, err := New(10)
+	require.NoError(t, err)
+
+	_, ch, stop := rb.Follow(0, 1)
+	stop()
+	stop()
+	_, _ = rb.Write([]byte("a\n"))
+	_, ok := <-ch
+	assert.False(t, ok)
+}
@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloud-gt/ai-sensors/client"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/endpoint"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/google/uuid"
+)
+
+const cliUsage = `usage: ai-sensors <command> [flags] [args]
+
+commands:
+  list                                   list commands and their status
+  add -name NAME [-dir DIR] [-tag a,b] [-autostart] COMMAND...
+                                         define a command
+  rm NAME|ID                             delete a command
+  start NAME|ID                          start a command
+  stop NAME|ID                           stop a command
+  restart NAME|ID                        stop a command if running, then start it
+  status [NAME|ID]                       show the status of one or all commands
+  logs [-f] [-n N] NAME|ID               print output, -f to follow it
+
+every command accepts:
+  -addr ADDR    server address, host:port or unix:///path/to.sock
+                (default $AI_SENSORS_ADDR or %s)
+  -token TOKEN  API token (default $AI_SENSORS_TOKEN)
+  -json         print JSON for scripts
+
+run without a command to start the server
+`
+
+// cliCommands are the subcommands handled by runCLI.
+var cliCommands = map[string]bool{
+	"list": true, "add": true, "rm": true, "start": true, "stop": true,
+	"restart": true, "status": true, "logs": true,
+}
+
+type cli struct {
+	api    *client.Client
+	json   bool
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// runCLI implements the client subcommands and returns the exit code.
+func runCLI(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || !cliCommands[args[0]] {
+		fmt.Fprintf(stderr, cliUsage, endpoint.Default)
+		return 2
+	}
+
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addr := fs.String("addr", envOr("AI_SENSORS_ADDR", endpoint.Default), "server address")
+	token := fs.String("token", os.Getenv("AI_SENSORS_TOKEN"), "API token")
+	asJSON := fs.Bool("json", false, "print JSON")
+
+	var run func(c *cli, ctx context.Context, args []string) error
+	switch args[0] {
+	case "list":
+		run = (*cli).list
+	case "add":
+		name := fs.String("name", "", "command name")
+		dir := fs.String("dir", ".", "working directory")
+		tags := fs.String("tag", "", "comma-separated tags")
+		autostart := fs.Bool("autostart", false, "start with the server")
+		run = func(c *cli, ctx context.Context, args []string) error {
+			return c.add(ctx, args, *name, *dir, *tags, *autostart)
+		}
+	case "rm":
+		run = (*cli).rm
+	case "start":
+		run = (*cli).start
+	case "stop":
+		run = (*cli).stop
+	case "restart":
+		run = (*cli).restart
+	case "status":
+		run = (*cli).status
+	case "logs":
+		follow := fs.Bool("f", false, "follow the output until the run ends")
+		n := fs.Int("n", 0, "print only the last N lines (default all)")
+		run = func(c *cli, ctx context.Context, args []string) error {
+			return c.logs(ctx, args, *follow, *n)
+		}
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	api, err := client.New(*addr, client.WithToken(*token))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	c := &cli{api: api, json: *asJSON, stdout: stdout, stderr: stderr}
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	if err := run(c, ctx, fs.Args()); err != nil {
+		var usage usageError
+		if errors.As(err, &usage) {
+			fmt.Fprintln(stderr, "usage: ai-sensors", string(usage))
+			return 2
+		}
+		fmt.Fprintf(stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+type usageError string
+
+func (e usageError) Error() string { return string(e) }
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+func (c *cli) printJSON(v any) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// resolve finds a command by ID or name.
+func (c *cli) resolve(ctx context.Context, ref string) (command.Command, error) {
+	commands, err := c.api.ListCommands(ctx)
+	if err != nil {
+		return command.Command{}, err
+	}
+	id, idErr := uuid.Parse(ref)
+	for _, cmd := range commands {
+		if (idErr == nil && cmd.ID == id) || cmd.Name == ref {
+			return cmd, nil
+		}
+	}
+	return command.Command{}, fmt.Errorf("no command named %q", ref)
+}
+
+func (c *cli) resolveArg(ctx context.Context, args []string, usage string) (command.Command, error) {
+	if len(args) != 1 {
+		return command.Command{}, usageError(usage)
+	}
+	return c.resolve(ctx, args[0])
+}
+
+type commandStatus struct {
+	ID          uuid.UUID      `json:"id"`
+	Name        string         `json:"name"`
+	Status      manager.Status `json:"status"`
+	Autostarted bool           `json:"autostarted"`
+	Watching    bool           `json:"watching"`
+}
+
+// statusOf reports a command that was never started as not_started.
+func (c *cli) statusOf(ctx context.Context, cmd command.Command) (commandStatus, error) {
+	s, err := c.api.Status(ctx, cmd.ID)
+	if errors.Is(err, client.ErrNotFound) {
+		s.Status, err = manager.StatusNotStarted, nil
+	}
+	return commandStatus{ID: cmd.ID, Name: cmd.Name, Status: s.Status, Autostarted: s.Autostarted, Watching: s.Watching}, err
+}
+
+func (c *cli) list(ctx context.Context, args []string) error {
+	if len(args) != 0 {
+		return usageError("list")
+	}
+	commands, err := c.api.ListCommands(ctx)
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		command.Command
+		Status manager.Status `json:"status"`
+	}
+	entries := make([]entry, len(commands))
+	for i, cmd := range commands {
+		s, err := c.statusOf(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		entries[i] = entry{Command: cmd, Status: s.Status}
+	}
+
+	if c.json {
+		return c.printJSON(map[string][]entry{"commands": entries})
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tCOMMAND")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.ID, e.Name, e.Status, e.Command.Command)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) add(ctx context.Context, args []string, name, dir, tags string, autostart bool) error {
+	if name == "" || len(args) == 0 {
+		return usageError("add -name NAME [-dir DIR] [-tag a,b] [-autostart] COMMAND...")
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	def := command.Command{
+		Name:      name,
+		Command:   strings.Join(args, " "),
+		WorkDir:   dir,
+		Autostart: autostart,
+	}
+	if tags != "" {
+		def.Tags = strings.Split(tags, ",")
+	}
+
+	cmd, err := c.api.CreateCommand(ctx, def)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(cmd)
+	}
+	fmt.Fprintln(c.stdout, cmd.ID)
+	return nil
+}
+
+func (c *cli) rm(ctx context.Context, args []string) error {
+	cmd, err := c.resolveArg(ctx, args, "rm NAME|ID")
+	if err != nil {
+		return err
+	}
+	if err := c.api.DeleteCommand(ctx, cmd.ID); err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(map[string]any{"id": cmd.ID, "deleted": true})
+	}
+	fmt.Fprintf(c.stderr, "Deleted %s\n", cmd.Name)
+	return nil
+}
+
+func (c *cli) start(ctx context.Context, args []string) error {
+	cmd, err := c.resolveArg(ctx, args, "start NAME|ID")
+	if err != nil {
+		return err
+	}
+	return c.startCommand(ctx, cmd)
+}
+
+func (c *cli) startCommand(ctx context.Context, cmd command.Command) error {
+	started, err := c.api.StartCommand(ctx, cmd.ID)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(map[string]any{"id": cmd.ID, "started": started})
+	}
+	if started {
+		fmt.Fprintf(c.stderr, "Started %s\n", cmd.Name)
+	} else {
+		fmt.Fprintf(c.stderr, "%s is already running\n", cmd.Name)
+	}
+	return nil
+}
+
+// stopCommand stops cmd and reports whether it was running.
+func (c *cli) stopCommand(ctx context.Context, cmd command.Command) (bool, error) {
+	err := c.api.StopCommand(ctx, cmd.ID)
+	if errors.Is(err, client.ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (c *cli) stop(ctx context.Context, args []string) error {
+	cmd, err := c.resolveArg(ctx, args, "stop NAME|ID")
+	if err != nil {
+		return err
+	}
+	stopped, err := c.stopCommand(ctx, cmd)
+	if err != nil {
+		return err
+	}
+	if c.json {
+		return c.printJSON(map[string]any{"id": cmd.ID, "stopped": stopped})
+	}
+	if stopped {
+		fmt.Fprintf(c.stderr, "Stopped %s\n", cmd.Name)
+	} else {
+		fmt.Fprintf(c.stderr, "%s is not running\n", cmd.Name)
+	}
+	return nil
+}
+
+func (c *cli) restart(ctx context.Context, args []string) error {
+	cmd, err := c.resolveArg(ctx, args, "restart NAME|ID")
+	if err != nil {
+		return err
+	}
+	if _, err := c.stopCommand(ctx, cmd); err != nil {
+		return err
+	}
+	return c.startCommand(ctx, cmd)
+}
+
+func (c *cli) status(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return usageError("status [NAME|ID]")
+	}
+	var commands []command.Command
+	if len(args) == 1 {
+		cmd, err := c.resolve(ctx, args[0])
+		if err != nil {
+			return err
+		}
+		commands = []command.Command{cmd}
+	} else {
+		var err error
+		if commands, err = c.api.ListCommands(ctx); err != nil {
+			return err
+		}
+	}
+
+	statuses := make([]commandStatus, len(commands))
+	for i, cmd := range commands {
+		s, err := c.statusOf(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		statuses[i] = s
+	}
+
+	if c.json {
+		if len(args) == 1 {
+			return c.printJSON(statuses[0])
+		}
+		return c.printJSON(map[string][]commandStatus{"commands": statuses})
+	}
+	tw := tabwriter.NewWriter(c.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATUS\tAUTOSTARTED\tWATCHING")
+	for _, s := range statuses {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%t\n", s.Name, s.Status, s.Autostarted, s.Watching)
+	}
+	return tw.Flush()
+}
+
+func (c *cli) logs(ctx context.Context, args []string, follow bool, n int) error {
+	cmd, err := c.resolveArg(ctx, args, "logs [-f] [-n N] NAME|ID")
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		return usageError("logs [-f] [-n N] NAME|ID")
+	}
+
+	printLine := func(line string) error {
+		if c.json {
+			return json.NewEncoder(c.stdout).Encode(map[string]string{"line": line})
+		}
+		_, err := fmt.Fprintln(c.stdout, line)
+		return err
+	}
+
+	if !follow {
+		var lines []string
+		if n > 0 {
+			lines, err = c.api.OutputLastN(ctx, cmd.ID, n)
+		} else {
+			lines, err = c.api.Output(ctx, cmd.ID)
+		}
+		if err != nil {
+			return err
+		}
+		for _, line := range lines {
+			if err := printLine(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// The server clamps lines to what it holds, so "all" is any large N.
+	if n == 0 {
+		n = 1 << 30
+	}
+	return c.api.FollowOutput(ctx, cmd.ID, n, printLine)
+}
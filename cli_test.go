@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runAgainst runs the CLI against addr and returns the exit code, stdout
+// and stderr.
+func runAgainst(t *testing.T, addr string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	args = append([]string{args[0], "-addr", addr}, args[1:]...)
+	code := runCLI(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func newCLITestServer(t *testing.T) string {
+	t.Helper()
+	store := command.NewStore(command.NewMemoryRepository())
+	mgr := manager.New(store)
+	ts := httptest.NewServer(server.New(store, mgr).Router())
+	t.Cleanup(func() {
+		_ = mgr.Shutdown(context.Background())
+		ts.Close()
+	})
+	return strings.TrimPrefix(ts.URL, "http://")
+}
+
+func TestCLI_Lifecycle(t *testing.T) {
+	addr := newCLITestServer(t)
+
+	code, out, errOut := runAgainst(t, addr, "add", "-name", "greet", "-dir", t.TempDir(), "echo", "hello;", "echo", "world")
+	require.Equal(t, 0, code, errOut)
+	assert.NotEmpty(t, strings.TrimSpace(out))
+
+	code, out, _ = runAgainst(t, addr, "list", "-json")
+	require.Equal(t, 0, code)
+	var list struct {
+		Commands []struct {
+			Name    string `json:"name"`
+			Command string `json:"command"`
+			Status  string `json:"status"`
+		} `json:"commands"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(out), &list))
+	require.Len(t, list.Commands, 1)
+	assert.Equal(t, "echo hello; echo world", list.Commands[0].Command)
+	assert.Equal(t, "not_started", list.Commands[0].Status)
+
+	code, _, errOut = runAgainst(t, addr, "start", "greet")
+	require.Equal(t, 0, code, errOut)
+
+	code, out, errOut = runAgainst(t, addr, "logs", "-f", "greet")
+	require.Equal(t, 0, code, errOut)
+	assert.Equal(t, "hello\nworld\n", out, "follows until the run ends")
+
+	code, out, _ = runAgainst(t, addr, "logs", "-n", "1", "-json", "greet")
+	require.Equal(t, 0, code)
+	assert.JSONEq(t, `{"line":"world"}`, out)
+
+	code, out, _ = runAgainst(t, addr, "status", "-json", "greet")
+	require.Equal(t, 0, code)
+	var status map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &status))
+	assert.Equal(t, "stopped", status["status"])
+
+	code, _, errOut = runAgainst(t, addr, "stop", "greet")
+	require.Equal(t, 0, code)
+	assert.Contains(t, errOut, "Stopped greet")
+
+	code, _, _ = runAgainst(t, addr, "rm", "greet")
+	require.Equal(t, 0, code)
+	code, _, errOut = runAgainst(t, addr, "status", "greet")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, `no command named "greet"`)
+}
+
+func TestCLI_Restart(t *testing.T) {
+	addr := newCLITestServer(t)
+	code, _, errOut := runAgainst(t, addr, "add", "-name", "server", "-dir", t.TempDir(), "sleep 60")
+	require.Equal(t, 0, code, errOut)
+
+	code, out, errOut := runAgainst(t, addr, "restart", "-json", "server")
+	require.Equal(t, 0, code, errOut)
+	assert.Contains(t, out, `"started": true`, "restarting a stopped command starts it")
+
+	code, out, _ = runAgainst(t, addr, "restart", "-json", "server")
+	require.Equal(t, 0, code)
+	assert.Contains(t, out, `"started": true`)
+
+	code, _, errOut = runAgainst(t, addr, "stop", "server")
+	require.Equal(t, 0, code)
+	assert.Contains(t, errOut, "Stopped server")
+}
+
+func TestCLI_Errors(t *testing.T) {
+	addr := newCLITestServer(t)
+
+	code, _, errOut := runAgainst(t, addr, "add", "-name", "x")
+	assert.Equal(t, 2, code)
+	assert.Contains(t, errOut, "usage: ai-sensors add")
+
+	code, _, errOut = runAgainst(t, addr, "add", "-name", "bad", "-dir", "/tmp", "true")
+	require.Equal(t, 0, code, errOut)
+	code, _, errOut = runAgainst(t, addr, "add", "-name", "bad", "-dir", "/tmp", "true")
+	assert.Equal(t, 1, code)
+	assert.Contains(t, errOut, "command already exists (HTTP 409)")
+
+	var stderr bytes.Buffer
+	assert.Equal(t, 2, runCLI(context.Background(), []string{"bogus"}, &bytes.Buffer{}, &stderr))
+	assert.Contains(t, stderr.String(), "usage: ai-sensors <command>")
+}
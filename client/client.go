@@ -0,0 +1,114 @@
+// Package client is a Go client for the ai-sensors HTTP API.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/cloud-gt/ai-sensors/endpoint"
+)
+
+// Client talks to one server. It is safe for concurrent use.
+type Client struct {
+	http    *http.Client
+	custom  *http.Client
+	baseURL string
+	token   string
+}
+
+type Option func(*Client)
+
+// WithToken sends token as a bearer token with every request.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithHTTPClient replaces the HTTP client, for example to set a timeout or
+// TLS configuration. It is ignored for Unix socket addresses, which need
+// their own transport.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		c.custom = client
+	}
+}
+
+// New returns a client for addr: a base URL (http:// or https://), a
+// host:port, or unix:///path/to.sock.
+func New(addr string, opts ...Option) (*Client, error) {
+	c := &Client{}
+	if strings.HasPrefix(addr, "https://") {
+		c.http = &http.Client{}
+		c.baseURL = strings.TrimSuffix(addr, "/")
+	} else {
+		client, baseURL, err := endpoint.HTTPClient(addr)
+		if err != nil {
+			return nil, err
+		}
+		c.http = client
+		c.baseURL = baseURL
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.custom != nil && !strings.HasPrefix(addr, "unix://") {
+		c.http = c.custom
+	}
+	return c, nil
+}
+
+// Do sends body, if non-nil, as JSON and decodes a JSON response into out,
+// if non-nil. It is the escape hatch for endpoints without a typed method.
+// Error responses are returned as *Error.
+func (c *Client) Do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send returns the response of a successful request; the caller closes
+// its body.
+func (c *Client) send(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, r)
+	if err != nil {
+		return nil, err
+	}
+	// The server accepts POSTs only as JSON, even those without a body.
+	if body != nil || method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		return nil, newError(resp)
+	}
+	return resp, nil
+}
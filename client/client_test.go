@@ -0,0 +1,212 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, h http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	ts := httptest.NewServer(h)
+	t.Cleanup(ts.Close)
+	c, err := New(ts.URL, opts...)
+	require.NoError(t, err)
+	return c
+}
+
+func TestDo_ErrorMapping(t *testing.T) {
+	tests := []struct {
+		status   int
+		body     string
+		sentinel error
+		message  string
+	}{
+		{http.StatusBadRequest, `{"error":"name is required"}`, ErrBadRequest, "name is required"},
+		{http.StatusUnauthorized, `{"error":"missing token"}`, ErrUnauthorized, "missing token"},
+		{http.StatusForbidden, `{"error":"denied"}`, ErrForbidden, "denied"},
+		{http.StatusNotFound, `{"error":"command not found"}`, ErrNotFound, "command not found"},
+		{http.StatusConflict, `{"error":"already running"}`, ErrConflict, "already running"},
+		{http.StatusFailedDependency, `{"error":"dependency failed"}`, ErrFailedDependency, "dependency failed"},
+		{http.StatusServiceUnavailable, `{"error":"shutting down"}`, ErrUnavailable, "shutting down"},
+		{http.StatusInternalServerError, `{"error":"boom"}`, ErrServer, "boom"},
+		{http.StatusBadGateway, `<html>bad gateway</html>`, ErrServer, "Bad Gateway"},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+
+			err := c.Do(t.Context(), http.MethodGet, "/x", nil, nil)
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tt.sentinel)
+
+			var apiErr *Error
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.status, apiErr.StatusCode)
+			assert.Equal(t, tt.message, apiErr.Message)
+		})
+	}
+}
+
+func TestDo_ErrorDoesNotMatchOtherSentinels(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	err := c.Do(t.Context(), http.MethodGet, "/x", nil, nil)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrConflict)
+	assert.NotErrorIs(t, err, ErrServer)
+}
+
+func TestDo_SendsTokenAndJSON(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/echo", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"ok":true}`)
+	}, WithToken("secret"))
+
+	var out struct {
+		OK bool `json:"ok"`
+	}
+	require.NoError(t, c.Do(t.Context(), http.MethodPost, "/echo", map[string]string{"a": "b"}, &out))
+	assert.True(t, out.OK)
+}
+
+func TestDo_ContextCancelled(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	err := c.Do(ctx, http.MethodGet, "/slow", nil, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestNew_UnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "aic")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "server.sock")
+
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/commands", r.URL.Path)
+		fmt.Fprint(w, `{"commands":[]}`)
+	})}
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Close() })
+
+	c, err := New("unix://" + path)
+	require.NoError(t, err)
+	commands, err := c.ListCommands(t.Context())
+	require.NoError(t, err)
+	assert.Empty(t, commands)
+}
+
+func TestFollowOutput(t *testing.T) {
+	id := uuid.New()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/commands/"+id.String()+"/output/stream", r.URL.Path)
+		assert.Equal(t, "2", r.URL.Query().Get("lines"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, ": ping\n\nevent: line\ndata: one\n\nevent: line\ndata: two\n\nevent: end\ndata: {}\n\nevent: line\ndata: never\n\n")
+	})
+
+	var lines []string
+	err := c.FollowOutput(t.Context(), id, 2, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, lines)
+}
+
+func TestFollowOutput_JoinsDataFields(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: line\ndata: 50%\ndata: 100%\n\nevent: line\ndata: \ndata: \n\nevent: end\ndata: {}\n\n")
+	})
+
+	var lines []string
+	err := c.FollowOutput(t.Context(), uuid.New(), 0, func(line string) error {
+		lines = append(lines, line)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"50%\n100%", "\n"}, lines)
+}
+
+func TestFollowOutput_CallbackError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: line\ndata: one\n\nevent: line\ndata: two\n\n")
+	})
+
+	boom := errors.New("boom")
+	var calls int
+	err := c.FollowOutput(t.Context(), uuid.New(), 0, func(string) error {
+		calls++
+		return boom
+	})
+	assert.ErrorIs(t, err, boom)
+	assert.Equal(t, 1, calls)
+}
+
+func TestFollowOutput_NotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":"command not found"}`)
+	})
+
+	err := c.FollowOutput(t.Context(), uuid.New(), 0, func(string) error { return nil })
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestEvents(t *testing.T) {
+	id := uuid.New()
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, id.String(), r.URL.Query().Get("command"))
+		assert.Equal(t, "run.started,run.exited", r.URL.Query().Get("type"))
+		assert.Equal(t, "7", r.URL.Query().Get("last_event_id"))
+		fmt.Fprintf(w, "id: 8\nevent: run.started\ndata: {\"id\":8,\"type\":\"run.started\",\"command_id\":%q,\"data\":{\"pid\":1}}\n\n", id)
+		fmt.Fprintf(w, "id: 9\nevent: run.exited\ndata: {\"id\":9,\"type\":\"run.exited\",\"command_id\":%q}\n\n", id)
+	})
+
+	var got []Event
+	err := c.Events(t.Context(), EventFilter{
+		Command: id,
+		Types:   []events.Type{events.RunStarted, events.RunExited},
+		After:   7,
+	}, func(e Event) error {
+		got = append(got, e)
+		if len(got) == 2 {
+			return ErrStop
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, uint64(8), got[0].ID)
+	assert.Equal(t, events.RunStarted, got[0].Type)
+	assert.Equal(t, id, got[0].CommandID)
+	assert.JSONEq(t, `{"pid":1}`, string(got[0].Data))
+	assert.Equal(t, events.RunExited, got[1].Type)
+}
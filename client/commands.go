@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/google/uuid"
+)
+
+func commandPath(id uuid.UUID, suffix string) string {
+	return "/commands/" + id.String() + suffix
+}
+
+// ListCommands returns every command definition, with secrets redacted.
+func (c *Client) ListCommands(ctx context.Context) ([]command.Command, error) {
+	return c.listCommands(ctx, "/commands")
+}
+
+// ListCommandsByTag returns the commands carrying tag.
+func (c *Client) ListCommandsByTag(ctx context.Context, tag string) ([]command.Command, error) {
+	return c.listCommands(ctx, "/commands?tag="+url.QueryEscape(tag))
+}
+
+func (c *Client) listCommands(ctx context.Context, path string) ([]command.Command, error) {
+	var result struct {
+		Commands []command.Command `json:"commands"`
+	}
+	if err := c.Do(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Commands, nil
+}
+
+// GetCommand returns the definition of a command.
+func (c *Client) GetCommand(ctx context.Context, id uuid.UUID) (command.Command, error) {
+	var cmd command.Command
+	err := c.Do(ctx, http.MethodGet, commandPath(id, ""), nil, &cmd)
+	return cmd, err
+}
+
+// CreateCommand defines cmd and returns it with its assigned ID. The ID of
+// cmd is ignored.
+func (c *Client) CreateCommand(ctx context.Context, cmd command.Command) (command.Command, error) {
+	var created command.Command
+	err := c.Do(ctx, http.MethodPost, "/commands", cmd, &created)
+	return created, err
+}
+
+func (c *Client) DeleteCommand(ctx context.Context, id uuid.UUID) error {
+	return c.Do(ctx, http.MethodDelete, commandPath(id, ""), nil, nil)
+}
+
+// StartCommand starts a command and reports whether it was started, as
+// opposed to already running.
+func (c *Client) StartCommand(ctx context.Context, id uuid.UUID) (bool, error) {
+	var result struct {
+		Started bool `json:"started"`
+	}
+	err := c.Do(ctx, http.MethodPost, commandPath(id, "/start"), nil, &result)
+	return result.Started, err
+}
+
+// StopCommand stops a command. It fails with ErrNotFound if the command
+// was never started.
+func (c *Client) StopCommand(ctx context.Context, id uuid.UUID) error {
+	return c.Do(ctx, http.MethodPost, commandPath(id, "/stop"), nil, nil)
+}
+
+type Status struct {
+	Status      manager.Status `json:"status"`
+	Autostarted bool           `json:"autostarted"`
+	Watching    bool           `json:"watching"`
+}
+
+// Status returns the state of a command. It fails with ErrNotFound if the
+// command was never started.
+func (c *Client) Status(ctx context.Context, id uuid.UUID) (Status, error) {
+	var s Status
+	err := c.Do(ctx, http.MethodGet, commandPath(id, "/status"), nil, &s)
+	return s, err
+}
+
+// Output returns the buffered output of the current or last run.
+func (c *Client) Output(ctx context.Context, id uuid.UUID) ([]string, error) {
+	return c.output(ctx, commandPath(id, "/output"))
+}
+
+// OutputLastN returns the last n lines of the buffered output.
+func (c *Client) OutputLastN(ctx context.Context, id uuid.UUID, n int) ([]string, error) {
+	return c.output(ctx, commandPath(id, "/output?lines="+strconv.Itoa(n)))
+}
+
+func (c *Client) output(ctx context.Context, path string) ([]string, error) {
+	var result struct {
+		Lines []string `json:"lines"`
+	}
+	if err := c.Do(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Lines, nil
+}
+
+// Runs returns the recorded runs of a command, oldest first.
+func (c *Client) Runs(ctx context.Context, id uuid.UUID) ([]manager.Run, error) {
+	var result struct {
+		Runs []manager.Run `json:"runs"`
+	}
+	if err := c.Do(ctx, http.MethodGet, commandPath(id, "/runs"), nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Runs, nil
+}
+
+// RunOptions tune Run. Zero values use the server defaults.
+type RunOptions struct {
+	// Timeout stops the command if it runs longer.
+	Timeout time.Duration
+	// Lines is how much of the output tail to return.
+	Lines int
+}
+
+type RunResult struct {
+	Run        manager.Run `json:"run"`
+	ExitCode   *int        `json:"exit_code"`
+	DurationMS int64       `json:"duration_ms"`
+	TimedOut   bool        `json:"timed_out"`
+	Output     []string    `json:"output"`
+}
+
+// Run starts a command, waits for it to finish and returns the result. It
+// fails with ErrConflict if the command is already running.
+func (c *Client) Run(ctx context.Context, id uuid.UUID, opts RunOptions) (RunResult, error) {
+	query := url.Values{"wait": {"true"}}
+	if opts.Timeout > 0 {
+		query.Set("timeout", opts.Timeout.String())
+	}
+	if opts.Lines > 0 {
+		query.Set("lines", strconv.Itoa(opts.Lines))
+	}
+	var result RunResult
+	err := c.Do(ctx, http.MethodPost, commandPath(id, "/run?"+query.Encode()), nil, &result)
+	return result, err
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Sentinel errors matched by an *Error with the corresponding status, so
+// callers can write errors.Is(err, client.ErrNotFound).
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrUnauthorized     = errors.New("unauthorized")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotFound         = errors.New("not found")
+	ErrConflict         = errors.New("conflict")
+	ErrFailedDependency = errors.New("failed dependency")
+	ErrUnavailable      = errors.New("service unavailable")
+	ErrServer           = errors.New("server error")
+)
+
+var statusErrors = map[int]error{
+	http.StatusBadRequest:         ErrBadRequest,
+	http.StatusUnauthorized:       ErrUnauthorized,
+	http.StatusForbidden:          ErrForbidden,
+	http.StatusNotFound:           ErrNotFound,
+	http.StatusConflict:           ErrConflict,
+	http.StatusFailedDependency:   ErrFailedDependency,
+	http.StatusServiceUnavailable: ErrUnavailable,
+}
+
+// Error is an error response from the server.
+type Error struct {
+	StatusCode int
+	// Message is the server's "error" field, or the status text if the
+	// body had none.
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (HTTP %d)", e.Message, e.StatusCode)
+}
+
+func (e *Error) Is(target error) bool {
+	if err, ok := statusErrors[e.StatusCode]; ok {
+		return err == target
+	}
+	return e.StatusCode >= 500 && target == ErrServer
+}
+
+func newError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	var body struct {
+		Error string `json:"error"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) == nil && body.Error != "" {
+		e.Message = body.Error
+	} else {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
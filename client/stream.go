@@ -0,0 +1,126 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/google/uuid"
+)
+
+// ErrStop may be returned by a stream callback to end the stream without
+// an error.
+var ErrStop = errors.New("stop streaming")
+
+// FollowOutput calls fn with the last n lines of a running command's
+// output, then with each new line, until the run ends, ctx is done or fn
+// returns an error. The server ends the stream early if fn falls too far
+// behind.
+func (c *Client) FollowOutput(ctx context.Context, id uuid.UUID, n int, fn func(line string) error) error {
+	path := commandPath(id, "/output/stream?lines="+strconv.Itoa(n))
+	return c.stream(ctx, path, func(event, data string) error {
+		switch event {
+		case "line":
+			return fn(data)
+		case "end":
+			return ErrStop
+		}
+		return nil
+	})
+}
+
+// Event is a lifecycle event. Data holds the type-specific payload
+// described in package events.
+type Event struct {
+	ID        uint64          `json:"id"`
+	Type      events.Type     `json:"type"`
+	Time      time.Time       `json:"time"`
+	CommandID uuid.UUID       `json:"command_id"`
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// EventFilter narrows an event stream. Zero values match everything.
+type EventFilter struct {
+	Command uuid.UUID
+	Types   []events.Type
+	// After resumes a stream: recent events with a higher ID are replayed.
+	After uint64
+}
+
+// Events calls fn with each event until ctx is done or fn returns an
+// error. The stream also ends, without an error, if the server cuts it off
+// for falling behind; resume it with the ID of the last event seen.
+func (c *Client) Events(ctx context.Context, filter EventFilter, fn func(Event) error) error {
+	query := url.Values{}
+	if filter.Command != uuid.Nil {
+		query.Set("command", filter.Command.String())
+	}
+	if len(filter.Types) > 0 {
+		types := make([]string, len(filter.Types))
+		for i, t := range filter.Types {
+			types[i] = string(t)
+		}
+		query.Set("type", strings.Join(types, ","))
+	}
+	if filter.After > 0 {
+		query.Set("last_event_id", strconv.FormatUint(filter.After, 10))
+	}
+
+	path := "/events"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.stream(ctx, path, func(_, data string) error {
+		var e Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			return err
+		}
+		return fn(e)
+	})
+}
+
+// stream reads server-sent events from path, calling fn with the type and
+// data of each, joining multiple data fields with newlines. Comments and
+// events without a type are skipped.
+func (c *Client) stream(ctx context.Context, path string, fn func(event, data string) error) error {
+	resp, err := c.send(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var event string
+	var data []string
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if event != "" {
+				if err := fn(event, strings.Join(data, "\n")); err != nil {
+					if errors.Is(err, ErrStop) {
+						return nil
+					}
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = append(data, strings.TrimPrefix(line, "data: "))
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return scanner.Err()
+}
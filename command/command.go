@@ -2,7 +2,13 @@ package command
 
 import (
 	"errors"
+	"slices"
+	"strings"
+	"unicode"
 
+	"github.com/cloud-gt/ai-sensors/diagnostics"
+	"github.com/cloud-gt/ai-sensors/outputdiff"
+	"github.com/cloud-gt/ai-sensors/redact"
 	"github.com/google/uuid"
 )
 
@@ -11,11 +17,37 @@ var (
 	ErrEmptyName    = errors.New("command name cannot be empty")
 	ErrEmptyCommand = errors.New("command string cannot be empty")
 	ErrEmptyWorkDir = errors.New("command work_dir cannot be empty")
+	ErrInvalidTag   = errors.New("command tags must be non-empty and contain no whitespace")
 )
 
 type Command struct {
-	ID      uuid.UUID `json:"id"`
-	Name    string    `json:"name"`
-	Command string    `json:"command"`
-	WorkDir string    `json:"work_dir"`
+	ID        uuid.UUID                 `json:"id"`
+	Name      string                    `json:"name"`
+	Command   string                    `json:"command"`
+	WorkDir   string                    `json:"work_dir"`
+	Readiness *Readiness                `json:"readiness,omitempty"`
+	DependsOn []uuid.UUID               `json:"depends_on,omitempty"`
+	Tags      []string                  `json:"tags,omitempty"`
+	Autostart bool                      `json:"autostart,omitempty"`
+	Watch     *Watch                    `json:"watch,omitempty"`
+	Matchers  []diagnostics.Definition  `json:"matchers,omitempty"`
+	Tests     *TestResults              `json:"tests,omitempty"`
+	Normalize *outputdiff.Normalization `json:"normalize,omitempty"`
+	Dedup     bool                      `json:"dedup,omitempty"`
+	Env       []EnvVar                  `json:"env,omitempty"`
+	Redact    *redact.Config            `json:"redact,omitempty"`
+	Limits    *Limits                   `json:"limits,omitempty"`
+}
+
+func (c Command) HasTag(tag string) bool {
+	return slices.Contains(c.Tags, tag)
+}
+
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if tag == "" || strings.ContainsFunc(tag, unicode.IsSpace) {
+			return ErrInvalidTag
+		}
+	}
+	return nil
 }
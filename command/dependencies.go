@@ -0,0 +1,92 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownDependency = errors.New("dependency does not exist")
+	ErrDependencyCycle   = errors.New("dependencies form a cycle")
+	ErrDependencyInUse   = errors.New("command is a dependency of another command")
+)
+
+// validateDependencies checks that cmd only depends on existing commands and
+// that adding or replacing it in commands does not introduce a cycle.
+func validateDependencies(commands []Command, cmd Command) error {
+	graph := make(map[uuid.UUID][]uuid.UUID, len(commands)+1)
+	for _, c := range commands {
+		graph[c.ID] = c.DependsOn
+	}
+	graph[cmd.ID] = cmd.DependsOn
+
+	for _, dep := range cmd.DependsOn {
+		if _, ok := graph[dep]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownDependency, dep)
+		}
+	}
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[uuid.UUID]int, len(graph))
+	var visit func(id uuid.UUID) error
+	visit = func(id uuid.UUID) error {
+		switch state[id] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, id)
+		case done:
+			return nil
+		}
+		state[id] = visiting
+		for _, dep := range graph[id] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[id] = done
+		return nil
+	}
+
+	return visit(cmd.ID)
+}
+
+// SortByDependencies orders ids so that every command comes after the
+// commands it depends on, directly or transitively. IDs that are not part of
+// commands are kept and treated as having no dependencies.
+func SortByDependencies(commands []Command, ids []uuid.UUID) []uuid.UUID {
+	graph := make(map[uuid.UUID][]uuid.UUID, len(commands))
+	for _, c := range commands {
+		graph[c.ID] = c.DependsOn
+	}
+
+	wanted := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	result := make([]uuid.UUID, 0, len(ids))
+	visited := make(map[uuid.UUID]bool, len(graph))
+	var visit func(id uuid.UUID)
+	visit = func(id uuid.UUID) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		for _, dep := range graph[id] {
+			visit(dep)
+		}
+		if wanted[id] {
+			result = append(result, id)
+		}
+	}
+
+	for _, id := range ids {
+		visit(id)
+	}
+
+	return result
+}
@@ -0,0 +1,77 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_CreateWithUnknownDependency(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+
+	_, err := store.Create(Command{
+		Name:      "api",
+		Command:   "go run ./cmd/api",
+		WorkDir:   "/tmp",
+		DependsOn: []uuid.UUID{uuid.New()},
+	})
+
+	assert.ErrorIs(t, err, ErrUnknownDependency)
+}
+
+func TestStore_UpdateRefusesDependencyCycle(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+	db, err := store.Create(Command{Name: "db", Command: "db-emulator", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	api, err := store.Create(Command{Name: "api", Command: "api", WorkDir: "/tmp", DependsOn: []uuid.UUID{db.ID}})
+	require.NoError(t, err)
+
+	db.DependsOn = []uuid.UUID{api.ID}
+	err = store.Update(db)
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+
+	api.DependsOn = []uuid.UUID{api.ID}
+	err = store.Update(api)
+	assert.ErrorIs(t, err, ErrDependencyCycle)
+
+	retrieved, err := store.Get(db.ID)
+	require.NoError(t, err)
+	assert.Empty(t, retrieved.DependsOn)
+}
+
+func TestStore_DeleteRefusesCommandInUse(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+	db, err := store.Create(Command{Name: "db", Command: "db-emulator", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	_, err = store.Create(Command{Name: "api", Command: "api", WorkDir: "/tmp", DependsOn: []uuid.UUID{db.ID}})
+	require.NoError(t, err)
+
+	err = store.Delete(db.ID)
+
+	assert.ErrorIs(t, err, ErrDependencyInUse)
+}
+
+func TestSortByDependencies(t *testing.T) {
+	db := Command{ID: uuid.New(), Name: "db"}
+	api := Command{ID: uuid.New(), Name: "api", DependsOn: []uuid.UUID{db.ID}}
+	web := Command{ID: uuid.New(), Name: "web", DependsOn: []uuid.UUID{api.ID}}
+	other := uuid.New()
+	commands := []Command{web, api, db}
+
+	t.Run("dependencies first", func(t *testing.T) {
+		sorted := SortByDependencies(commands, []uuid.UUID{web.ID, db.ID, api.ID})
+		assert.Equal(t, []uuid.UUID{db.ID, api.ID, web.ID}, sorted)
+	})
+
+	t.Run("transitive through missing ids", func(t *testing.T) {
+		sorted := SortByDependencies(commands, []uuid.UUID{web.ID, db.ID})
+		assert.Equal(t, []uuid.UUID{db.ID, web.ID}, sorted)
+	})
+
+	t.Run("unknown ids kept", func(t *testing.T) {
+		sorted := SortByDependencies(commands, []uuid.UUID{other, api.ID})
+		assert.ElementsMatch(t, []uuid.UUID{other, api.ID}, sorted)
+	})
+}
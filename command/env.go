@@ -0,0 +1,77 @@
+package command
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cloud-gt/ai-sensors/redact"
+)
+
+var ErrInvalidEnv = errors.New("env names must be non-empty and contain no '='")
+
+// EnvVar is set in the environment of a command's process. The value of a
+// secret variable is redacted from captured output and from API responses.
+// A secret with no Value is inherited from the server's environment, so it
+// need not be stored with the command.
+type EnvVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value,omitempty"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// Environ returns the variables to add to the inherited environment, in
+// KEY=VALUE form. Variables without a value are inherited as they are.
+func (c Command) Environ() []string {
+	var env []string
+	for _, v := range c.Env {
+		if v.Value != "" {
+			env = append(env, v.Name+"="+v.Value)
+		}
+	}
+	return env
+}
+
+// SecretValues returns the values of the variables marked secret, resolving
+// inherited ones from the server's environment.
+func (c Command) SecretValues() []string {
+	var values []string
+	for _, v := range c.Env {
+		if !v.Secret {
+			continue
+		}
+		value := v.Value
+		if value == "" {
+			value = os.Getenv(v.Name)
+		}
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// Redacted returns a copy of the command with secret values masked, for
+// showing to clients.
+func (c Command) Redacted() Command {
+	if !slices.ContainsFunc(c.Env, func(v EnvVar) bool { return v.Secret && v.Value != "" }) {
+		return c
+	}
+	c.Env = slices.Clone(c.Env)
+	for i, v := range c.Env {
+		if v.Secret && v.Value != "" {
+			c.Env[i].Value = redact.Placeholder
+		}
+	}
+	return c
+}
+
+func validateEnv(env []EnvVar) error {
+	for _, v := range env {
+		if v.Name == "" || strings.Contains(v.Name, "=") {
+			return ErrInvalidEnv
+		}
+	}
+	return nil
+}
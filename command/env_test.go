@@ -0,0 +1,51 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand_Environ(t *testing.T) {
+	cmd := Command{Env: []EnvVar{
+		{Name: "PORT", Value: "8080"},
+		{Name: "API_TOKEN", Value: "tok-123", Secret: true},
+		{Name: "HOME_TOKEN", Secret: true},
+	}}
+
+	assert.Equal(t, []string{"PORT=8080", "API_TOKEN=tok-123"}, cmd.Environ())
+}
+
+func TestCommand_SecretValuesResolvesInherited(t *testing.T) {
+	t.Setenv("INHERITED_SECRET", "from-server")
+	cmd := Command{Env: []EnvVar{
+		{Name: "PORT", Value: "8080"},
+		{Name: "API_TOKEN", Value: "tok-123", Secret: true},
+		{Name: "INHERITED_SECRET", Secret: true},
+		{Name: "UNSET_SECRET", Secret: true},
+	}}
+
+	assert.Equal(t, []string{"tok-123", "from-server"}, cmd.SecretValues())
+}
+
+func TestCommand_Redacted(t *testing.T) {
+	cmd := Command{Env: []EnvVar{
+		{Name: "PORT", Value: "8080"},
+		{Name: "API_TOKEN", Value: "tok-123", Secret: true},
+	}}
+
+	redacted := cmd.Redacted()
+
+	assert.Equal(t, "8080", redacted.Env[0].Value)
+	assert.Equal(t, "[REDACTED]", redacted.Env[1].Value)
+	assert.Equal(t, "tok-123", cmd.Env[1].Value, "the original is left untouched")
+}
+
+func TestStore_CreateRejectsInvalidEnv(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+
+	for _, env := range [][]EnvVar{{{Value: "x"}}, {{Name: "A=B"}}} {
+		_, err := store.Create(Command{Name: "srv", Command: "serve", WorkDir: "/tmp", Env: env})
+		assert.ErrorIs(t, err, ErrInvalidEnv)
+	}
+}
@@ -0,0 +1,42 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidLimits = errors.New("invalid resource limits")
+
+// Limits caps the resources a command's process tree may use. Zero fields
+// are unlimited.
+type Limits struct {
+	// MemoryMB is the memory ceiling in mebibytes.
+	MemoryMB int64 `json:"memory_mb,omitempty"`
+	// CPU is the CPU bandwidth in cores, e.g. 0.5 or 2.
+	CPU float64 `json:"cpu,omitempty"`
+	// Processes caps live processes and threads, stopping fork bombs.
+	Processes int `json:"processes,omitempty"`
+	// OpenFiles caps file descriptors per process.
+	OpenFiles uint64 `json:"open_files,omitempty"`
+	// Timeout stops the command after it has run this long.
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+func (l *Limits) validate() error {
+	if l == nil {
+		return nil
+	}
+	switch {
+	case l.MemoryMB < 0:
+		return fmt.Errorf("%w: memory_mb cannot be negative", ErrInvalidLimits)
+	case l.CPU < 0:
+		return fmt.Errorf("%w: cpu cannot be negative", ErrInvalidLimits)
+	case l.CPU > 0 && l.CPU < 0.01:
+		return fmt.Errorf("%w: cpu must be at least 0.01", ErrInvalidLimits)
+	case l.Processes < 0:
+		return fmt.Errorf("%w: processes cannot be negative", ErrInvalidLimits)
+	case l.Timeout < 0:
+		return fmt.Errorf("%w: timeout cannot be negative", ErrInvalidLimits)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+var ErrInvalidReadiness = errors.New("invalid readiness check")
+
+type ReadinessType string
+
+const (
+	ReadinessTCP  ReadinessType = "tcp"
+	ReadinessHTTP ReadinessType = "http"
+	ReadinessLog  ReadinessType = "log"
+)
+
+// Readiness declares the checks the manager evaluates after a command starts.
+// The command is ready once every check passes.
+type Readiness struct {
+	Checks   []ReadinessCheck `json:"checks"`
+	Interval Duration         `json:"interval,omitempty"`
+	Timeout  Duration         `json:"timeout,omitempty"`
+}
+
+type ReadinessCheck struct {
+	Type    ReadinessType `json:"type"`
+	Address string        `json:"address,omitempty"`
+	URL     string        `json:"url,omitempty"`
+	Pattern string        `json:"pattern,omitempty"`
+}
+
+func (r *Readiness) validate() error {
+	if r == nil {
+		return nil
+	}
+	if len(r.Checks) == 0 {
+		return fmt.Errorf("%w: at least one check is required", ErrInvalidReadiness)
+	}
+	if r.Interval < 0 || r.Timeout < 0 {
+		return fmt.Errorf("%w: interval and timeout must not be negative", ErrInvalidReadiness)
+	}
+	for _, c := range r.Checks {
+		switch c.Type {
+		case ReadinessTCP:
+			if c.Address == "" {
+				return fmt.Errorf("%w: tcp check requires an address", ErrInvalidReadiness)
+			}
+		case ReadinessHTTP:
+			if c.URL == "" {
+				return fmt.Errorf("%w: http check requires a url", ErrInvalidReadiness)
+			}
+		case ReadinessLog:
+			if c.Pattern == "" {
+				return fmt.Errorf("%w: log check requires a pattern", ErrInvalidReadiness)
+			}
+			if _, err := regexp.Compile(c.Pattern); err != nil {
+				return fmt.Errorf("%w: %v", ErrInvalidReadiness, err)
+			}
+		default:
+			return fmt.Errorf("%w: unknown type %q", ErrInvalidReadiness, c.Type)
+		}
+	}
+	return nil
+}
@@ -1,45 +1,194 @@
 package command
 
 import (
+	"fmt"
+	"slices"
 	"sync"
 
+	"github.com/cloud-gt/ai-sensors/diagnostics"
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/outputdiff"
+	"github.com/cloud-gt/ai-sensors/redact"
 	"github.com/google/uuid"
 )
 
 type Store struct {
 	repo     Repository
+	policy   Policy
+	events   *events.Bus
 	mu       sync.RWMutex
 	commands []Command
+	health   Health
 }
 
-func NewStore(repo Repository) *Store {
-	return &Store{
+// Health describes how the store's repository has behaved.
+type Health struct {
+	Loaded        bool   `json:"loaded"`
+	LoadError     string `json:"load_error,omitempty"`
+	WriteFailures uint64 `json:"write_failures"`
+	// LastWriteError is cleared by the next successful write.
+	LastWriteError string `json:"last_write_error,omitempty"`
+}
+
+// Policy decides which definitions the store accepts. Definitions read by
+// Load are not checked.
+type Policy interface {
+	AllowDefine(cmd Command) error
+	AllowDelete(cmd Command) error
+}
+
+type Option func(*Store)
+
+// WithPolicy makes Create, Update and Delete consult p before changing
+// anything.
+func WithPolicy(p Policy) Option {
+	return func(s *Store) {
+		s.policy = p
+	}
+}
+
+// WithEvents publishes command.created, command.updated and
+// command.deleted to bus after each successful change.
+func WithEvents(bus *events.Bus) Option {
+	return func(s *Store) {
+		s.events = bus
+	}
+}
+
+func NewStore(repo Repository, opts ...Option) *Store {
+	s := &Store{
 		repo:     repo,
 		commands: []Command{},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *Store) Create(cmd Command) (Command, error) {
+// Load replaces the in-memory commands with the ones held by the repository.
+func (s *Store) Load() error {
+	commands, err := s.repo.Load()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		err = validateLoaded(commands)
+	}
+	if err != nil {
+		s.health.LoadError = err.Error()
+		return err
+	}
+	s.commands = commands
+	s.health.Loaded = true
+	s.health.LoadError = ""
+	return nil
+}
+
+// validateLoaded applies the checks Create makes to every command read from
+// the repository, which may have been written by hand or by a config file.
+func validateLoaded(commands []Command) error {
+	for _, cmd := range commands {
+		if err := cmd.validate(); err != nil {
+			return fmt.Errorf("command %q: %w", cmd.Name, err)
+		}
+		if err := validateDependencies(commands, cmd); err != nil {
+			return fmt.Errorf("command %q: %w", cmd.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks a complete definition, except for its dependencies, which
+// can only be checked against the other commands.
+func (cmd Command) validate() error {
 	if cmd.Name == "" {
-		return Command{}, ErrEmptyName
+		return ErrEmptyName
 	}
 	if cmd.Command == "" {
-		return Command{}, ErrEmptyCommand
+		return ErrEmptyCommand
 	}
 	if cmd.WorkDir == "" {
-		return Command{}, ErrEmptyWorkDir
+		return ErrEmptyWorkDir
+	}
+	return cmd.validateSettings()
+}
+
+// validateSettings checks the optional parts of a definition.
+func (cmd Command) validateSettings() error {
+	if err := cmd.Readiness.validate(); err != nil {
+		return err
+	}
+	if err := validateTags(cmd.Tags); err != nil {
+		return err
+	}
+	if err := cmd.Watch.validate(); err != nil {
+		return err
+	}
+	if err := cmd.Tests.validate(); err != nil {
+		return err
+	}
+	if err := diagnostics.Validate(cmd.Matchers); err != nil {
+		return err
+	}
+	if err := outputdiff.Validate(cmd.Normalize); err != nil {
+		return err
+	}
+	if err := validateEnv(cmd.Env); err != nil {
+		return err
+	}
+	if err := redact.Validate(cmd.Redact); err != nil {
+		return err
+	}
+	if err := cmd.Limits.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Health reports the repository's load and write status.
+func (s *Store) Health() Health {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.health
+}
+
+// save must be called with s.mu held.
+func (s *Store) save() error {
+	if err := s.repo.Save(s.commands); err != nil {
+		s.health.WriteFailures++
+		s.health.LastWriteError = err.Error()
+		return err
+	}
+	s.health.LastWriteError = ""
+	return nil
+}
+
+func (s *Store) Create(cmd Command) (Command, error) {
+	if err := cmd.validate(); err != nil {
+		return Command{}, err
+	}
+	if s.policy != nil {
+		if err := s.policy.AllowDefine(cmd); err != nil {
+			return Command{}, err
+		}
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	cmd.ID = uuid.Must(uuid.NewV7())
+	if err := validateDependencies(s.commands, cmd); err != nil {
+		return Command{}, err
+	}
 	s.commands = append(s.commands, cmd)
 
-	if err := s.repo.Save(s.commands); err != nil {
+	if err := s.save(); err != nil {
 		s.commands = s.commands[:len(s.commands)-1]
 		return Command{}, err
 	}
+	s.events.Publish(events.CommandCreated, cmd.ID, cmd.Redacted())
 
 	return cmd, nil
 }
@@ -58,16 +207,29 @@ func (s *Store) Get(id uuid.UUID) (Command, error) {
 }
 
 func (s *Store) Update(cmd Command) error {
+	if err := cmd.validateSettings(); err != nil {
+		return err
+	}
+	if s.policy != nil {
+		if err := s.policy.AllowDefine(cmd); err != nil {
+			return err
+		}
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	for i, existing := range s.commands {
 		if existing.ID == cmd.ID {
+			if err := validateDependencies(s.commands, cmd); err != nil {
+				return err
+			}
 			s.commands[i] = cmd
-			if err := s.repo.Save(s.commands); err != nil {
+			if err := s.save(); err != nil {
 				s.commands[i] = existing
 				return err
 			}
+			s.events.Publish(events.CommandUpdated, cmd.ID, cmd.Redacted())
 			return nil
 		}
 	}
@@ -79,14 +241,32 @@ func (s *Store) Delete(id uuid.UUID) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if s.policy != nil {
+		for _, cmd := range s.commands {
+			if cmd.ID != id {
+				continue
+			}
+			if err := s.policy.AllowDelete(cmd); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, cmd := range s.commands {
+		if slices.Contains(cmd.DependsOn, id) {
+			return fmt.Errorf("%w: required by %s", ErrDependencyInUse, cmd.Name)
+		}
+	}
+
 	for i, cmd := range s.commands {
 		if cmd.ID == id {
 			deleted := s.commands[i]
 			s.commands = append(s.commands[:i], s.commands[i+1:]...)
-			if err := s.repo.Save(s.commands); err != nil {
+			if err := s.save(); err != nil {
 				s.commands = append(s.commands[:i], append([]Command{deleted}, s.commands[i:]...)...)
 				return err
 			}
+			s.events.Publish(events.CommandDeleted, id, nil)
 			return nil
 		}
 	}
@@ -116,3 +296,17 @@ func (s *Store) GetByName(name string) (Command, error) {
 
 	return Command{}, ErrNotFound
 }
+
+func (s *Store) ListByTag(tag string) ([]Command, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := []Command{}
+	for _, cmd := range s.commands {
+		if cmd.HasTag(tag) {
+			result = append(result, cmd)
+		}
+	}
+
+	return result, nil
+}
@@ -1,8 +1,10 @@
 package command
 
 import (
+	"errors"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -205,3 +207,216 @@ func TestStore_ConcurrentAccessSafety(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestStore_CreateRejectsInvalidReadiness(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+
+	tests := []struct {
+		name      string
+		readiness *Readiness
+	}{
+		{"no checks", &Readiness{}},
+		{"tcp without address", &Readiness{Checks: []ReadinessCheck{{Type: ReadinessTCP}}}},
+		{"http without url", &Readiness{Checks: []ReadinessCheck{{Type: ReadinessHTTP}}}},
+		{"invalid pattern", &Readiness{Checks: []ReadinessCheck{{Type: ReadinessLog, Pattern: "("}}}},
+		{"unknown type", &Readiness{Checks: []ReadinessCheck{{Type: "grpc"}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := store.Create(Command{
+				Name:      "with-readiness",
+				Command:   "npm run dev",
+				WorkDir:   "/tmp",
+				Readiness: tt.readiness,
+			})
+			assert.ErrorIs(t, err, ErrInvalidReadiness)
+		})
+	}
+}
+
+func TestStore_ListByTag(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+	api, err := store.Create(Command{Name: "api", Command: "go run .", WorkDir: "/tmp", Tags: []string{"backend"}})
+	require.NoError(t, err)
+	_, err = store.Create(Command{Name: "web", Command: "npm run dev", WorkDir: "/tmp", Tags: []string{"frontend"}})
+	require.NoError(t, err)
+
+	backend, err := store.ListByTag("backend")
+	require.NoError(t, err)
+	assert.Equal(t, []Command{api}, backend)
+
+	none, err := store.ListByTag("unknown")
+	require.NoError(t, err)
+	assert.Empty(t, none)
+}
+
+func TestStore_LoadFromRepository(t *testing.T) {
+	repo := NewMemoryRepository()
+	existing := Command{ID: uuid.New(), Name: "watcher", Command: "go test ./...", WorkDir: "/tmp", Autostart: true}
+	require.NoError(t, repo.Save([]Command{existing}))
+
+	store := NewStore(repo)
+	require.NoError(t, store.Load())
+
+	commands, err := store.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Command{existing}, commands)
+}
+
+func TestStore_LoadRejectsInvalidDefinitions(t *testing.T) {
+	a, b := uuid.New(), uuid.New()
+	tests := []struct {
+		name     string
+		commands []Command
+		want     error
+	}{
+		{"missing work dir", []Command{{ID: a, Name: "a", Command: "x"}}, ErrEmptyWorkDir},
+		{"bad readiness pattern", []Command{{ID: a, Name: "a", Command: "x", WorkDir: "/tmp",
+			Readiness: &Readiness{Checks: []ReadinessCheck{{Type: ReadinessLog, Pattern: "("}}}}}, ErrInvalidReadiness},
+		{"bad watch", []Command{{ID: a, Name: "a", Command: "x", WorkDir: "/tmp", Watch: &Watch{Mode: "sometimes"}}}, ErrInvalidWatch},
+		{"unknown dependency", []Command{{ID: a, Name: "a", Command: "x", WorkDir: "/tmp", DependsOn: []uuid.UUID{b}}}, ErrUnknownDependency},
+		{"cycle", []Command{
+			{ID: a, Name: "a", Command: "x", WorkDir: "/tmp", DependsOn: []uuid.UUID{b}},
+			{ID: b, Name: "b", Command: "x", WorkDir: "/tmp", DependsOn: []uuid.UUID{a}},
+		}, ErrDependencyCycle},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := NewMemoryRepository()
+			require.NoError(t, repo.Save(tt.commands))
+			store := NewStore(repo)
+
+			err := store.Load()
+			assert.ErrorIs(t, err, tt.want)
+			assert.False(t, store.Health().Loaded)
+			assert.NotEmpty(t, store.Health().LoadError)
+			all, _ := store.List()
+			assert.Empty(t, all)
+		})
+	}
+}
+
+func TestStore_CreateRejectsInvalidWatch(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+
+	for _, watch := range []*Watch{
+		{Mode: "sometimes"},
+		{Include: []string{"[a-"}},
+		{Debounce: Duration(-1)},
+	} {
+		_, err := store.Create(Command{Name: "tests", Command: "go test ./...", WorkDir: "/tmp", Watch: watch})
+		assert.ErrorIs(t, err, ErrInvalidWatch)
+	}
+}
+
+func TestStore_CreateRejectsInvalidTestResults(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+
+	for _, tests := range []*TestResults{
+		{Format: "xunit"},
+		{Format: TestFormatJUnit},
+		{Format: TestFormatTAP, Path: "out.tap"},
+	} {
+		_, err := store.Create(Command{Name: "tests", Command: "npm test", WorkDir: "/tmp", Tests: tests})
+		assert.ErrorIs(t, err, ErrInvalidTestResults)
+	}
+}
+
+func TestTestResults_ArtifactPath(t *testing.T) {
+	assert.Equal(t, "/src/app/reports/junit.xml", (&TestResults{Path: "reports/junit.xml"}).ArtifactPath("/src/app"))
+	assert.Equal(t, "/tmp/junit.xml", (&TestResults{Path: "/tmp/junit.xml"}).ArtifactPath("/src/app"))
+}
+
+type denyNames map[string]error
+
+func (d denyNames) AllowDefine(cmd Command) error { return d[cmd.Name] }
+func (d denyNames) AllowDelete(cmd Command) error { return d["delete:"+cmd.Name] }
+
+func TestStore_PolicyGuardsChanges(t *testing.T) {
+	errDenied := errors.New("denied")
+	repo := NewMemoryRepository()
+	require.NoError(t, repo.Save([]Command{{ID: uuid.New(), Name: "preloaded", Command: "rm -rf /", WorkDir: "/"}}))
+	store := NewStore(repo, WithPolicy(denyNames{"blocked": errDenied, "delete:kept": errDenied}))
+	require.NoError(t, store.Load(), "loaded definitions bypass the policy")
+
+	_, err := store.Create(Command{Name: "blocked", Command: "x", WorkDir: "/tmp"})
+	assert.ErrorIs(t, err, errDenied)
+	all, _ := store.List()
+	assert.Len(t, all, 1)
+
+	kept, err := store.Create(Command{Name: "kept", Command: "x", WorkDir: "/tmp"})
+	require.NoError(t, err)
+
+	kept.Name = "blocked"
+	assert.ErrorIs(t, store.Update(kept), errDenied)
+	got, _ := store.Get(kept.ID)
+	assert.Equal(t, "kept", got.Name)
+
+	assert.ErrorIs(t, store.Delete(kept.ID), errDenied)
+	_, err = store.Get(kept.ID)
+	assert.NoError(t, err)
+}
+
+func TestStore_CreateRejectsInvalidLimits(t *testing.T) {
+	store := NewStore(NewMemoryRepository())
+
+	for _, limits := range []*Limits{
+		{MemoryMB: -1},
+		{CPU: -0.5},
+		{CPU: 0.001},
+		{Processes: -3},
+		{Timeout: Duration(-time.Second)},
+	} {
+		_, err := store.Create(Command{Name: "build", Command: "make", WorkDir: "/tmp", Limits: limits})
+		assert.ErrorIs(t, err, ErrInvalidLimits)
+	}
+
+	_, err := store.Create(Command{Name: "build", Command: "make", WorkDir: "/tmp", Limits: &Limits{MemoryMB: 512, CPU: 2, Timeout: Duration(time.Minute)}})
+	assert.NoError(t, err)
+}
+
+type flakyRepository struct {
+	MemoryRepository
+	loadErr error
+	saveErr error
+}
+
+func (r *flakyRepository) Load() ([]Command, error) {
+	if r.loadErr != nil {
+		return nil, r.loadErr
+	}
+	return r.MemoryRepository.Load()
+}
+
+func (r *flakyRepository) Save(commands []Command) error {
+	if r.saveErr != nil {
+		return r.saveErr
+	}
+	return r.MemoryRepository.Save(commands)
+}
+
+func TestStore_HealthTracksLoadAndWrites(t *testing.T) {
+	repo := &flakyRepository{loadErr: errors.New("corrupt file")}
+	store := NewStore(repo)
+	assert.False(t, store.Health().Loaded)
+
+	require.Error(t, store.Load())
+	assert.Equal(t, Health{LoadError: "corrupt file"}, store.Health())
+
+	repo.loadErr = nil
+	require.NoError(t, store.Load())
+	assert.Equal(t, Health{Loaded: true}, store.Health())
+
+	repo.saveErr = errors.New("disk full")
+	_, err := store.Create(Command{Name: "a", Command: "true", WorkDir: "/tmp"})
+	require.Error(t, err)
+	_, err = store.Create(Command{Name: "b", Command: "true", WorkDir: "/tmp"})
+	require.Error(t, err)
+	assert.Equal(t, Health{Loaded: true, WriteFailures: 2, LastWriteError: "disk full"}, store.Health())
+
+	repo.saveErr = nil
+	_, err = store.Create(Command{Name: "c", Command: "true", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	assert.Equal(t, Health{Loaded: true, WriteFailures: 2}, store.Health())
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+var ErrInvalidTestResults = errors.New("invalid test results source")
+
+type TestFormat string
+
+const (
+	// TestFormatGo parses `go test` output, plain or -json.
+	TestFormatGo TestFormat = "go"
+	// TestFormatTAP parses Test Anything Protocol output.
+	TestFormatTAP TestFormat = "tap"
+	// TestFormatJUnit reads a JUnit XML artifact once the run finishes.
+	TestFormatJUnit TestFormat = "junit"
+)
+
+// TestResults tells the manager where a command reports its test results.
+// Path is only used by artifact formats and is resolved against work_dir
+// when relative.
+type TestResults struct {
+	Format TestFormat `json:"format"`
+	Path   string     `json:"path,omitempty"`
+}
+
+// ArtifactPath returns the artifact location for a command running in workDir.
+func (t *TestResults) ArtifactPath(workDir string) string {
+	if filepath.IsAbs(t.Path) {
+		return t.Path
+	}
+	return filepath.Join(workDir, t.Path)
+}
+
+func (t *TestResults) validate() error {
+	if t == nil {
+		return nil
+	}
+	switch t.Format {
+	case TestFormatGo, TestFormatTAP:
+		if t.Path != "" {
+			return fmt.Errorf("%w: format %q reads stdout and takes no path", ErrInvalidTestResults, t.Format)
+		}
+	case TestFormatJUnit:
+		if t.Path == "" {
+			return fmt.Errorf("%w: format %q requires a path", ErrInvalidTestResults, t.Format)
+		}
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrInvalidTestResults, t.Format)
+	}
+	return nil
+}
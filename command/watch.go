@@ -0,0 +1,56 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/cloud-gt/ai-sensors/watcher"
+)
+
+var ErrInvalidWatch = errors.New("invalid watch rule")
+
+type WatchMode string
+
+const (
+	// WatchRestart stops a running process and starts it again on change.
+	WatchRestart WatchMode = "restart"
+	// WatchRerunIfIdle starts the command on change only when it is not running.
+	WatchRerunIfIdle WatchMode = "rerun_if_idle"
+)
+
+// Watch re-executes a command when files below its work_dir change. Include
+// and Exclude are globs relative to work_dir; an empty Include matches every file.
+type Watch struct {
+	Include  []string  `json:"include,omitempty"`
+	Exclude  []string  `json:"exclude,omitempty"`
+	Debounce Duration  `json:"debounce,omitempty"`
+	Mode     WatchMode `json:"mode,omitempty"`
+}
+
+func (w *Watch) EffectiveMode() WatchMode {
+	if w.Mode == "" {
+		return WatchRestart
+	}
+	return w.Mode
+}
+
+func (w *Watch) validate() error {
+	if w == nil {
+		return nil
+	}
+	switch w.Mode {
+	case "", WatchRestart, WatchRerunIfIdle:
+	default:
+		return fmt.Errorf("%w: unknown mode %q", ErrInvalidWatch, w.Mode)
+	}
+	if w.Debounce < 0 {
+		return fmt.Errorf("%w: debounce must not be negative", ErrInvalidWatch)
+	}
+	for _, p := range slices.Concat(w.Include, w.Exclude) {
+		if err := watcher.ValidatePattern(p); err != nil {
+			return fmt.Errorf("%w: pattern %q", ErrInvalidWatch, p)
+		}
+	}
+	return nil
+}
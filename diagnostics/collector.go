@@ -0,0 +1,127 @@
+package diagnostics
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type Diagnostic struct {
+	File     string   `json:"file,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Severity Severity `json:"severity"`
+	Code     string   `json:"code,omitempty"`
+	Message  string   `json:"message"`
+	Source   string   `json:"source"`
+	Count    int      `json:"count"`
+}
+
+type key struct {
+	file     string
+	line     int
+	column   int
+	severity Severity
+	message  string
+}
+
+// Collector runs problem matchers over output lines and keeps the resulting
+// diagnostics, deduplicated and in first-seen order.
+type Collector struct {
+	mu       sync.Mutex
+	matchers []*matcher
+	diags    []Diagnostic
+	index    map[key]int
+	onNew    func(Diagnostic)
+}
+
+func NewCollector(defs []Definition) (*Collector, error) {
+	c := &Collector{index: make(map[key]int)}
+	for _, def := range defs {
+		m, err := compile(def)
+		if err != nil {
+			return nil, err
+		}
+		c.matchers = append(c.matchers, m)
+	}
+	return c, nil
+}
+
+// OnNew registers fn to be called with each diagnostic the first time it
+// is seen; repeats only bump its count. fn runs on the feeding goroutine.
+func (c *Collector) OnNew(fn func(Diagnostic)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onNew = fn
+}
+
+func (c *Collector) Feed(line string) {
+	c.mu.Lock()
+	var fresh []Diagnostic
+	for _, m := range c.matchers {
+		fields, ok := m.feed(line)
+		if !ok || fields["message"] == "" {
+			continue
+		}
+		if d, isNew := c.add(m, fields); isNew {
+			fresh = append(fresh, d)
+		}
+	}
+	onNew := c.onNew
+	c.mu.Unlock()
+
+	if onNew != nil {
+		for _, d := range fresh {
+			onNew(d)
+		}
+	}
+}
+
+func (c *Collector) add(m *matcher, fields map[string]string) (Diagnostic, bool) {
+	d := Diagnostic{
+		File:     fields["file"],
+		Severity: normalizeSeverity(fields["severity"], m.severity),
+		Code:     fields["code"],
+		Message:  strings.TrimSpace(fields["message"]),
+		Source:   m.name,
+		Count:    1,
+	}
+	d.Line, _ = strconv.Atoi(fields["line"])
+	d.Column, _ = strconv.Atoi(fields["column"])
+
+	k := key{file: d.File, line: d.Line, column: d.Column, severity: d.Severity, message: d.Message}
+	if i, seen := c.index[k]; seen {
+		c.diags[i].Count++
+		return c.diags[i], false
+	}
+	c.index[k] = len(c.diags)
+	c.diags = append(c.diags, d)
+	return d, true
+}
+
+// Diagnostics returns the collected diagnostics, optionally restricted to
+// one severity.
+func (c *Collector) Diagnostics(severity Severity) []Diagnostic {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := make([]Diagnostic, 0, len(c.diags))
+	for _, d := range c.diags {
+		if severity == "" || d.Severity == severity {
+			result = append(result, d)
+		}
+	}
+	return result
+}
+
+func normalizeSeverity(s string, fallback Severity) Severity {
+	switch strings.ToLower(s) {
+	case "error", "fatal", "err":
+		return SeverityError
+	case "warning", "warn":
+		return SeverityWarning
+	case "info", "note", "hint":
+		return SeverityInfo
+	}
+	return fallback
+}
@@ -0,0 +1,122 @@
+package diagnostics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func collect(t *testing.T, defs []Definition, output string) []Diagnostic {
+	t.Helper()
+	c, err := NewCollector(defs)
+	require.NoError(t, err)
+	for _, line := range strings.Split(output, "\n") {
+		c.Feed(line)
+	}
+	return c.Diagnostics("")
+}
+
+func TestCollector_GoBuild(t *testing.T) {
+	diags := collect(t, []Definition{{Name: "go"}}, `# example.com/app
+./main.go:10:2: undefined: foo
+./main.go:10:2: undefined: foo
+vet: server/handler.go:42: result of fmt.Sprintf call not used`)
+
+	require.Len(t, diags, 2)
+	assert.Equal(t, Diagnostic{
+		File: "./main.go", Line: 10, Column: 2, Severity: SeverityError,
+		Message: "undefined: foo", Source: "go", Count: 2,
+	}, diags[0])
+	assert.Equal(t, "server/handler.go", diags[1].File)
+	assert.Equal(t, 42, diags[1].Line)
+	assert.Equal(t, 0, diags[1].Column)
+}
+
+func TestCollector_Tsc(t *testing.T) {
+	diags := collect(t, []Definition{{Name: "tsc"}}, `src/app.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.
+src/util.ts:8:1 - warning TS6133: 'x' is declared but its value is never read.`)
+
+	require.Len(t, diags, 2)
+	assert.Equal(t, "src/app.ts", diags[0].File)
+	assert.Equal(t, 3, diags[0].Line)
+	assert.Equal(t, 7, diags[0].Column)
+	assert.Equal(t, "TS2322", diags[0].Code)
+	assert.Equal(t, "src/util.ts", diags[1].File)
+	assert.Equal(t, SeverityWarning, diags[1].Severity)
+}
+
+func TestCollector_EslintMultiLine(t *testing.T) {
+	diags := collect(t, []Definition{{Name: "eslint"}}, `
+/repo/src/index.js
+  1:10  error    'foo' is defined but never used  no-unused-vars
+  2:3   warning  Unexpected console statement     no-console
+
+/repo/src/other.js
+  5:1  error  Missing semicolon  semi
+
+✖ 3 problems (2 errors, 1 warning)`)
+
+	require.Len(t, diags, 3)
+	assert.Equal(t, Diagnostic{
+		File: "/repo/src/index.js", Line: 1, Column: 10, Severity: SeverityError,
+		Code: "no-unused-vars", Message: "'foo' is defined but never used", Source: "eslint", Count: 1,
+	}, diags[0])
+	assert.Equal(t, SeverityWarning, diags[1].Severity)
+	assert.Equal(t, "/repo/src/index.js", diags[1].File)
+	assert.Equal(t, "/repo/src/other.js", diags[2].File)
+	assert.Equal(t, "semi", diags[2].Code)
+}
+
+func TestCollector_Rustc(t *testing.T) {
+	diags := collect(t, []Definition{{Name: "rustc"}}, "error[E0425]: cannot find value `x` in this scope\n --> src/main.rs:2:5\n  |\nerror: aborting due to 1 previous error")
+
+	require.Len(t, diags, 1)
+	assert.Equal(t, "src/main.rs", diags[0].File)
+	assert.Equal(t, 2, diags[0].Line)
+	assert.Equal(t, "E0425", diags[0].Code)
+	assert.Equal(t, "cannot find value `x` in this scope", diags[0].Message)
+}
+
+func TestCollector_CustomMatcherAndSeverityFilter(t *testing.T) {
+	c, err := NewCollector([]Definition{{
+		Name:     "lint",
+		Severity: SeverityWarning,
+		Patterns: []Pattern{{Regexp: `^(?P<file>\S+):(?P<line>\d+) (?P<message>.+)$`}},
+	}})
+	require.NoError(t, err)
+
+	c.Feed("a.py:3 line too long")
+
+	assert.Empty(t, c.Diagnostics(SeverityError))
+	warnings := c.Diagnostics(SeverityWarning)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "lint", warnings[0].Source)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate([]Definition{{Name: "go"}, {Name: "eslint", Severity: SeverityWarning}}))
+	assert.ErrorIs(t, Validate([]Definition{{Name: "javac"}}), ErrUnknownMatcher)
+	assert.ErrorIs(t, Validate([]Definition{{Name: "x", Patterns: []Pattern{{Regexp: "("}}}}), ErrInvalidMatcher)
+	assert.ErrorIs(t, Validate([]Definition{{Name: "x", Patterns: []Pattern{{Regexp: `^(?P<file>.+)$`}}}}), ErrInvalidMatcher)
+	assert.ErrorIs(t, Validate([]Definition{{Name: "x", Patterns: []Pattern{
+		{Regexp: `^(?P<file>.+)$`, Loop: true},
+		{Regexp: `^(?P<message>.+)$`},
+	}}}), ErrInvalidMatcher)
+}
+
+func TestCollector_OnNew(t *testing.T) {
+	c, err := NewCollector([]Definition{{Name: "go"}})
+	require.NoError(t, err)
+
+	var seen []Diagnostic
+	c.OnNew(func(d Diagnostic) { seen = append(seen, d) })
+	c.Feed("./main.go:10:2: undefined: foo")
+	c.Feed("./main.go:10:2: undefined: foo")
+	c.Feed("./main.go:12:1: undefined: bar")
+
+	require.Len(t, seen, 2, "repeats are not reported again")
+	assert.Equal(t, "undefined: foo", seen[0].Message)
+	assert.Equal(t, "undefined: bar", seen[1].Message)
+}
@@ -0,0 +1,213 @@
+package diagnostics
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+var (
+	ErrInvalidMatcher = errors.New("invalid problem matcher")
+	ErrUnknownMatcher = errors.New("unknown built-in problem matcher")
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Definition describes a problem matcher. Patterns are matched against
+// consecutive lines; the fields captured by all of them form a diagnostic.
+// When the last pattern loops, every following line it matches produces a
+// diagnostic sharing the fields captured by the earlier patterns.
+//
+// Patterns use the named groups file, line, column, severity, code and
+// message. A definition with a name and no patterns refers to a built-in.
+type Definition struct {
+	Name     string    `json:"name"`
+	Severity Severity  `json:"severity,omitempty"`
+	Patterns []Pattern `json:"patterns,omitempty"`
+}
+
+type Pattern struct {
+	Regexp string `json:"regexp"`
+	Loop   bool   `json:"loop,omitempty"`
+}
+
+var builtins = map[string]Definition{
+	"go": {
+		Name:     "go",
+		Severity: SeverityError,
+		Patterns: []Pattern{{
+			Regexp: `^(?:vet: )?(?P<file>[^\s:]+\.go):(?P<line>\d+):(?:(?P<column>\d+):)?\s+(?P<message>.+)$`,
+		}},
+	},
+	"tsc": {
+		Name:     "tsc",
+		Severity: SeverityError,
+		Patterns: []Pattern{{
+			Regexp: `^(?:(?P<file>[^\s(]+)\((?P<line>\d+),(?P<column>\d+)\):|(?P<file>\S+):(?P<line>\d+):(?P<column>\d+) -)\s+(?P<severity>error|warning)\s+(?P<code>TS\d+):\s+(?P<message>.+)$`,
+		}},
+	},
+	"eslint": {
+		Name:     "eslint",
+		Severity: SeverityError,
+		Patterns: []Pattern{
+			{Regexp: `^(?P<file>\S.*)$`},
+			{Regexp: `^\s+(?P<line>\d+):(?P<column>\d+)\s+(?P<severity>error|warning|info)\s+(?P<message>.+?)(?:\s{2,}(?P<code>\S+))?$`, Loop: true},
+		},
+	},
+	"rustc": {
+		Name:     "rustc",
+		Severity: SeverityError,
+		Patterns: []Pattern{
+			{Regexp: `^(?P<severity>error|warning)(?:\[(?P<code>\w+)\])?: (?P<message>.+)$`},
+			{Regexp: `^\s*--> (?P<file>[^:]+):(?P<line>\d+):(?P<column>\d+)$`},
+		},
+	},
+}
+
+// Builtins returns the names of the built-in matchers.
+func Builtins() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Resolve returns the built-in definition referenced by def, or def itself
+// when it declares its own patterns.
+func Resolve(def Definition) (Definition, error) {
+	if len(def.Patterns) > 0 {
+		return def, nil
+	}
+	builtin, ok := builtins[def.Name]
+	if !ok {
+		return Definition{}, fmt.Errorf("%w: %q", ErrUnknownMatcher, def.Name)
+	}
+	if def.Severity != "" {
+		builtin.Severity = def.Severity
+	}
+	return builtin, nil
+}
+
+// Validate checks that every definition resolves and compiles.
+func Validate(defs []Definition) error {
+	for _, def := range defs {
+		if _, err := compile(def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type matcher struct {
+	name     string
+	severity Severity
+	patterns []*regexp.Regexp
+	loop     bool
+
+	// Progress through a multi-line match.
+	next     int
+	captured map[string]string
+}
+
+func compile(def Definition) (*matcher, error) {
+	def, err := Resolve(def)
+	if err != nil {
+		return nil, err
+	}
+	if def.Name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidMatcher)
+	}
+	switch def.Severity {
+	case "", SeverityError, SeverityWarning, SeverityInfo:
+	default:
+		return nil, fmt.Errorf("%w: %s: unknown severity %q", ErrInvalidMatcher, def.Name, def.Severity)
+	}
+
+	m := &matcher{name: def.Name, severity: def.Severity}
+	if m.severity == "" {
+		m.severity = SeverityError
+	}
+	for i, p := range def.Patterns {
+		re, err := regexp.Compile(p.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidMatcher, def.Name, err)
+		}
+		if p.Loop && i != len(def.Patterns)-1 {
+			return nil, fmt.Errorf("%w: %s: only the last pattern can loop", ErrInvalidMatcher, def.Name)
+		}
+		m.patterns = append(m.patterns, re)
+		m.loop = p.Loop
+	}
+	if !slices.ContainsFunc(m.patterns, func(re *regexp.Regexp) bool { return re.SubexpIndex("message") >= 0 }) {
+		return nil, fmt.Errorf("%w: %s: a pattern must capture message", ErrInvalidMatcher, def.Name)
+	}
+	return m, nil
+}
+
+// feed advances the matcher with one line and returns the fields of a
+// completed diagnostic, if any.
+func (m *matcher) feed(line string) (map[string]string, bool) {
+	if m.next > 0 {
+		if fields, ok := m.match(m.next, line); ok {
+			return m.advance(fields)
+		}
+		m.next, m.captured = 0, nil
+	}
+
+	fields, ok := m.match(0, line)
+	if !ok {
+		return nil, false
+	}
+	m.captured = nil
+	return m.advance(fields)
+}
+
+func (m *matcher) advance(fields map[string]string) (map[string]string, bool) {
+	merged := make(map[string]string, len(m.captured)+len(fields))
+	for k, v := range m.captured {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	last := len(m.patterns) - 1
+	switch {
+	case m.next < last:
+		m.next++
+		m.captured = merged
+		return nil, false
+	case m.loop && last > 0:
+		return merged, true
+	default:
+		m.next, m.captured = 0, nil
+		return merged, true
+	}
+}
+
+func (m *matcher) match(i int, line string) (map[string]string, bool) {
+	re := m.patterns[i]
+	sub := re.FindStringSubmatch(line)
+	if sub == nil {
+		return nil, false
+	}
+	fields := map[string]string{}
+	for j, name := range re.SubexpNames() {
+		if name == "" || sub[j] == "" {
+			continue
+		}
+		if _, set := fields[name]; !set {
+			fields[name] = sub[j]
+		}
+	}
+	return fields, true
+}
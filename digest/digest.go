@@ -0,0 +1,263 @@
+// Package digest condenses command output into a bounded text view meant to
+// be read by a language model: repeated lines are collapsed, the head and
+// tail are kept, and lines that look like errors or warnings are preferred
+// over everything else.
+package digest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultMaxChars is the budget used when the caller does not set one.
+	DefaultMaxChars = 4000
+
+	headGroups   = 5
+	tailGroups   = 5
+	errorContext = 2
+	warnContext  = 1
+
+	// maxLineChars truncates single lines so one minified blob cannot use up
+	// the whole budget.
+	maxLineChars = 400
+)
+
+var (
+	errorRe = regexp.MustCompile(`(?i)\b(error|errors|err|fail|failed|failure|fatal|panic|exception|traceback)\b|^FAIL`)
+	warnRe  = regexp.MustCompile(`(?i)\b(warn|warning|warnings|deprecated)\b`)
+)
+
+type level int
+
+const (
+	levelNone level = iota
+	levelWarn
+	levelError
+)
+
+// Range is an inclusive span of line numbers.
+type Range struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+}
+
+// Elided reports what the digest left out.
+type Elided struct {
+	// Lines counts lines omitted entirely, listed as Ranges of 1-based line
+	// numbers.
+	Lines  int     `json:"lines"`
+	Ranges []Range `json:"ranges"`
+	// Repeated counts shown lines folded into the previous identical line.
+	Repeated int `json:"repeated"`
+	// Errors and Warnings count omitted lines that matched those heuristics.
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+	// Truncated counts shown lines cut to maxLineChars.
+	Truncated int `json:"truncated"`
+}
+
+type Digest struct {
+	Text       string `json:"text"`
+	TotalLines int    `json:"total_lines"`
+	ShownLines int    `json:"shown_lines"`
+	Errors     int    `json:"errors"`
+	Warnings   int    `json:"warnings"`
+	Elided     Elided `json:"elided"`
+}
+
+// group is a run of identical consecutive lines.
+type group struct {
+	text  string
+	first int // 0-based index of the first line
+	count int
+	level level
+}
+
+func (g group) render() (string, bool) {
+	text, truncated := g.text, false
+	if len(text) > maxLineChars {
+		text, truncated = text[:maxLineChars]+"…", true
+	}
+	if g.count > 1 {
+		text = fmt.Sprintf("%s  [×%d]", text, g.count)
+	}
+	return text, truncated
+}
+
+// gapMarkerChars bounds the length of an elision marker line, so that the
+// budget can account for markers before the selection is final.
+const gapMarkerChars = 40
+
+func gapMarker(n int) string {
+	if n == 1 {
+		return "[… 1 line elided …]"
+	}
+	return fmt.Sprintf("[… %d lines elided …]", n)
+}
+
+// Build condenses lines into at most maxChars characters of text. A
+// non-positive maxChars selects DefaultMaxChars. A budget too small for a
+// single elision marker yields an empty text; Elided still describes the
+// output.
+func Build(lines []string, maxChars int) Digest {
+	if maxChars <= 0 {
+		maxChars = DefaultMaxChars
+	}
+
+	groups := collapse(lines)
+	d := Digest{TotalLines: len(lines), Elided: Elided{Ranges: []Range{}}}
+	for _, g := range groups {
+		switch g.level {
+		case levelError:
+			d.Errors += g.count
+		case levelWarn:
+			d.Warnings += g.count
+		}
+	}
+
+	selected := selectGroups(groups, maxChars)
+	d.Text = render(groups, selected, &d)
+	// Only the marker standing for the whole output can overflow, when the
+	// budget is shorter than the marker itself.
+	if len(d.Text) > maxChars {
+		d.Text = ""
+	}
+	return d
+}
+
+func collapse(lines []string) []group {
+	var groups []group
+	for i, line := range lines {
+		if n := len(groups); n > 0 && groups[n-1].text == line {
+			groups[n-1].count++
+			continue
+		}
+		g := group{text: line, first: i, count: 1}
+		switch {
+		case errorRe.MatchString(line):
+			g.level = levelError
+		case warnRe.MatchString(line):
+			g.level = levelWarn
+		}
+		groups = append(groups, g)
+	}
+	return groups
+}
+
+// selectGroups picks which groups to show, in priority order: the last
+// lines, errors with context, the first lines, warnings with context, then
+// the rest growing inwards from both ends. Every pick is charged for its
+// text and for the elision markers it adds or removes, so the rendered
+// digest never exceeds the budget.
+func selectGroups(groups []group, maxChars int) []bool {
+	selected := make([]bool, len(groups))
+
+	total := 0
+	for _, g := range groups {
+		text, _ := g.render()
+		total += len(text) + 1
+	}
+	if total <= maxChars {
+		for i := range selected {
+			selected[i] = true
+		}
+		return selected
+	}
+
+	// Nothing is selected yet, so everything is one gap.
+	budget := maxChars - gapMarkerChars
+	pick := func(i int) {
+		if i < 0 || i >= len(groups) || selected[i] {
+			return
+		}
+		// Selecting i splits the gap around it into the parts left of and
+		// right of i, either of which may be empty.
+		gaps := -1
+		if i > 0 && !selected[i-1] {
+			gaps++
+		}
+		if i < len(groups)-1 && !selected[i+1] {
+			gaps++
+		}
+		text, _ := groups[i].render()
+		cost := len(text) + 1 + gaps*gapMarkerChars
+		if cost > budget {
+			return
+		}
+		budget -= cost
+		selected[i] = true
+	}
+	withContext := func(lvl level, context int) {
+		for i, g := range groups {
+			if g.level != lvl {
+				continue
+			}
+			pick(i)
+			for c := 1; c <= context; c++ {
+				pick(i - c)
+				pick(i + c)
+			}
+		}
+	}
+
+	for i := max(0, len(groups)-tailGroups); i < len(groups); i++ {
+		pick(i)
+	}
+	withContext(levelError, errorContext)
+	for i := range min(headGroups, len(groups)) {
+		pick(i)
+	}
+	withContext(levelWarn, warnContext)
+	for i, j := len(groups)-tailGroups-1, headGroups; i >= j; i, j = i-1, j+1 {
+		pick(i)
+		pick(j)
+	}
+	return selected
+}
+
+func render(groups []group, selected []bool, d *Digest) string {
+	var b strings.Builder
+	gapStart := -1
+	closeGap := func(end int) {
+		if gapStart < 0 {
+			return
+		}
+		last := groups[end-1]
+		r := Range{From: groups[gapStart].first + 1, To: last.first + last.count}
+		d.Elided.Ranges = append(d.Elided.Ranges, r)
+		b.WriteString(gapMarker(r.To - r.From + 1))
+		b.WriteByte('\n')
+		gapStart = -1
+	}
+
+	for i, g := range groups {
+		if !selected[i] {
+			if gapStart < 0 {
+				gapStart = i
+			}
+			d.Elided.Lines += g.count
+			switch g.level {
+			case levelError:
+				d.Elided.Errors += g.count
+			case levelWarn:
+				d.Elided.Warnings += g.count
+			}
+			continue
+		}
+
+		closeGap(i)
+		text, truncated := g.render()
+		if truncated {
+			d.Elided.Truncated++
+		}
+		b.WriteString(text)
+		b.WriteByte('\n')
+		d.ShownLines += g.count
+		d.Elided.Repeated += g.count - 1
+	}
+	closeGap(len(groups))
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
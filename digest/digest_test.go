@@ -0,0 +1,115 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func numbered(n int, format string) []string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = fmt.Sprintf(format, i+1)
+	}
+	return lines
+}
+
+func TestBuild_SmallOutputIsKept(t *testing.T) {
+	d := Build([]string{"compiling", "done"}, 100)
+
+	assert.Equal(t, "compiling\ndone", d.Text)
+	assert.Equal(t, 2, d.TotalLines)
+	assert.Equal(t, 2, d.ShownLines)
+	assert.Equal(t, Elided{Ranges: []Range{}}, d.Elided)
+}
+
+func TestBuild_CollapsesRepeats(t *testing.T) {
+	lines := []string{"start"}
+	for range 50 {
+		lines = append(lines, "waiting for changes...")
+	}
+	lines = append(lines, "rebuilt")
+
+	d := Build(lines, 1000)
+
+	assert.Equal(t, "start\nwaiting for changes...  [×50]\nrebuilt", d.Text)
+	assert.Equal(t, 52, d.ShownLines)
+	assert.Equal(t, 49, d.Elided.Repeated)
+}
+
+func TestBuild_KeepsErrorsHeadAndTail(t *testing.T) {
+	lines := numbered(500, "step %d ok")
+	lines[249] = "main.go:12: undefined: foo (error)"
+
+	d := Build(lines, 1200)
+
+	assert.LessOrEqual(t, len(d.Text), 1200)
+	assert.Contains(t, d.Text, "step 1 ok")
+	assert.Contains(t, d.Text, "step 500 ok")
+	assert.Contains(t, d.Text, "undefined: foo")
+	assert.Contains(t, d.Text, "step 248 ok", "error context is kept")
+	assert.Contains(t, d.Text, "step 252 ok", "error context is kept")
+	assert.Contains(t, d.Text, "lines elided")
+
+	assert.Equal(t, 1, d.Errors)
+	assert.Zero(t, d.Elided.Errors)
+	assert.Equal(t, 500, d.ShownLines+d.Elided.Lines)
+	require.NotEmpty(t, d.Elided.Ranges)
+	elided := 0
+	for _, r := range d.Elided.Ranges {
+		elided += r.To - r.From + 1
+	}
+	assert.Equal(t, d.Elided.Lines, elided)
+}
+
+func TestBuild_ReportsDroppedErrors(t *testing.T) {
+	lines := numbered(300, "FAIL: test %d")
+
+	d := Build(lines, 500)
+
+	assert.LessOrEqual(t, len(d.Text), 500)
+	assert.Equal(t, 300, d.Errors)
+	assert.Equal(t, 300-d.ShownLines, d.Elided.Errors)
+	assert.Positive(t, d.Elided.Errors)
+}
+
+func TestBuild_TruncatesLongLines(t *testing.T) {
+	long := strings.Repeat("x", 5000)
+
+	d := Build([]string{"a", long, "b"}, 1000)
+
+	assert.LessOrEqual(t, len(d.Text), 1000)
+	assert.Equal(t, 1, d.Elided.Truncated)
+	assert.Contains(t, d.Text, "…")
+}
+
+func TestBuild_NeverExceedsBudget(t *testing.T) {
+	for _, lines := range [][]string{
+		numbered(2000, "line %d with some warning text"),
+		numbered(300, "step %d failed: error"),
+		{"a", strings.Repeat("x", 5000), "b"},
+	} {
+		for maxChars := 1; maxChars <= 1000; maxChars += 1 + maxChars/16 {
+			d := Build(lines, maxChars)
+			assert.LessOrEqual(t, len(d.Text), maxChars, maxChars)
+		}
+	}
+}
+
+func TestBuild_BudgetBelowMarker(t *testing.T) {
+	d := Build(numbered(100, "line %d"), 10)
+
+	assert.Empty(t, d.Text)
+	assert.Equal(t, 100, d.Elided.Lines)
+	assert.Equal(t, []Range{{From: 1, To: 100}}, d.Elided.Ranges)
+}
+
+func TestBuild_DefaultBudget(t *testing.T) {
+	d := Build(numbered(5000, "line %d"), 0)
+
+	assert.LessOrEqual(t, len(d.Text), DefaultMaxChars)
+	assert.Greater(t, d.Elided.Lines, 0)
+}
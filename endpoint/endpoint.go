@@ -0,0 +1,125 @@
+// Package endpoint parses the server address format shared by the server
+// and its clients: a TCP host:port, an http:// URL, or unix:///path/to.sock.
+package endpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Default is where the server listens unless told otherwise. Binding to
+// loopback keeps the API, which runs shell commands, off the network.
+const Default = "127.0.0.1:3000"
+
+// DefaultSocketMode restricts a Unix socket to its owner.
+const DefaultSocketMode fs.FileMode = 0o600
+
+const unixScheme = "unix://"
+
+var ErrInvalidAddress = errors.New("invalid address")
+
+// Address is a parsed server address.
+type Address struct {
+	Network string // "tcp" or "unix"
+	Address string // host:port or socket path
+}
+
+// Parse accepts host:port, :port, http://host:port and unix:///path.
+func Parse(addr string) (Address, error) {
+	switch {
+	case strings.HasPrefix(addr, unixScheme):
+		path := strings.TrimPrefix(addr, unixScheme)
+		if path == "" {
+			return Address{}, fmt.Errorf("%w: %q has no socket path", ErrInvalidAddress, addr)
+		}
+		return Address{Network: "unix", Address: path}, nil
+	case strings.HasPrefix(addr, "http://"):
+		addr = strings.TrimSuffix(strings.TrimPrefix(addr, "http://"), "/")
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return Address{}, fmt.Errorf("%w: %q: %v", ErrInvalidAddress, addr, err)
+	}
+	return Address{Network: "tcp", Address: addr}, nil
+}
+
+func (a Address) String() string {
+	if a.Network == "unix" {
+		return unixScheme + a.Address
+	}
+	return a.Address
+}
+
+// Public reports whether a TCP address listens beyond loopback.
+func (a Address) Public() bool {
+	if a.Network != "tcp" {
+		return false
+	}
+	host, _, _ := net.SplitHostPort(a.Address)
+	if host == "localhost" {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
+
+// Listen opens a listener on addr. A Unix socket gets socketMode; a stale
+// socket left by a previous run is replaced, but any other file at the path
+// is left alone.
+func Listen(addr string, socketMode fs.FileMode) (net.Listener, error) {
+	a, err := Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	if a.Network == "tcp" {
+		return net.Listen("tcp", a.Address)
+	}
+
+	if info, err := os.Lstat(a.Address); err == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", a.Address)
+		}
+		if conn, err := net.Dial("unix", a.Address); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", a.Address)
+		}
+		if err := os.Remove(a.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", a.Address)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(a.Address, socketMode); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// HTTPClient returns a client that reaches addr and the base URL to use
+// with it. For a Unix socket the URL host is a placeholder; every request is
+// dialed to the socket.
+func HTTPClient(addr string) (*http.Client, string, error) {
+	a, err := Parse(addr)
+	if err != nil {
+		return nil, "", err
+	}
+	if a.Network == "tcp" {
+		return &http.Client{}, "http://" + a.Address, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, "unix", a.Address)
+	}
+	return &http.Client{Transport: transport}, "http://unix", nil
+}
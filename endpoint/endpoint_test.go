@@ -0,0 +1,126 @@
+package endpoint
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketPath returns a short path; Unix socket paths are limited to about
+// a hundred bytes, which t.TempDir can exceed.
+func socketPath(t *testing.T) string {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "ais")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "s.sock")
+}
+
+func TestParse(t *testing.T) {
+	for in, want := range map[string]Address{
+		"127.0.0.1:3000":        {Network: "tcp", Address: "127.0.0.1:3000"},
+		":3000":                 {Network: "tcp", Address: ":3000"},
+		"http://localhost:3000": {Network: "tcp", Address: "localhost:3000"},
+		"unix:///run/ais.sock":  {Network: "unix", Address: "/run/ais.sock"},
+	} {
+		got, err := Parse(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"unix://", "localhost", "http://"} {
+		_, err := Parse(in)
+		assert.ErrorIs(t, err, ErrInvalidAddress, in)
+	}
+}
+
+func TestAddress_Public(t *testing.T) {
+	for in, want := range map[string]bool{
+		"127.0.0.1:3000":    false,
+		"[::1]:3000":        false,
+		"localhost:3000":    false,
+		":3000":             true,
+		"0.0.0.0:3000":      true,
+		"192.168.1.10:3000": true,
+		"unix:///tmp/s":     false,
+	} {
+		a, err := Parse(in)
+		require.NoError(t, err)
+		assert.Equal(t, want, a.Public(), in)
+	}
+}
+
+func TestListen_UnixSocketMode(t *testing.T) {
+	path := socketPath(t)
+
+	l, err := Listen("unix://"+path, 0o600)
+	require.NoError(t, err)
+	defer l.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	assert.NotZero(t, info.Mode()&os.ModeSocket)
+}
+
+func TestListen_ReplacesStaleSocket(t *testing.T) {
+	path := socketPath(t)
+
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+
+	l, err = Listen("unix://"+path, DefaultSocketMode)
+	require.NoError(t, err)
+	l.Close()
+}
+
+func TestListen_RefusesSocketInUse(t *testing.T) {
+	path := socketPath(t)
+
+	l, err := Listen("unix://"+path, DefaultSocketMode)
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, err = Listen("unix://"+path, DefaultSocketMode)
+	assert.Error(t, err)
+}
+
+func TestListen_RefusesRegularFile(t *testing.T) {
+	path := socketPath(t)
+	require.NoError(t, os.WriteFile(path, []byte("keep me"), 0o644))
+
+	_, err := Listen("unix://"+path, DefaultSocketMode)
+
+	assert.Error(t, err)
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "keep me", string(data))
+}
+
+func TestHTTPClient_OverUnixSocket(t *testing.T) {
+	addr := "unix://" + socketPath(t)
+	l, err := Listen(addr, DefaultSocketMode)
+	require.NoError(t, err)
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello "+r.URL.Path)
+	})}
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Close()
+
+	client, base, err := HTTPClient(addr)
+	require.NoError(t, err)
+
+	resp, err := client.Get(base + "/commands")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "hello /commands", string(body))
+}
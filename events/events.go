@@ -0,0 +1,141 @@
+// Package events carries lifecycle events from the store and manager to
+// subscribers such as the /events stream.
+package events
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Type string
+
+// Data carries, per type: the redacted command for command.created and
+// command.updated; the run (manager.Run) for run.*; the diagnostic for
+// output.matched; nothing for the rest.
+const (
+	CommandCreated   Type = "command.created"
+	CommandUpdated   Type = "command.updated"
+	CommandDeleted   Type = "command.deleted"
+	RunStarted       Type = "run.started"
+	RunRestarted     Type = "run.restarted"
+	RunExited        Type = "run.exited"
+	CommandReady     Type = "command.ready"
+	CommandUnhealthy Type = "command.unhealthy"
+	OutputMatched    Type = "output.matched"
+)
+
+// historySize is how many past events a reconnecting subscriber can
+// catch up on.
+const historySize = 256
+
+type Event struct {
+	ID        uint64    `json:"id"`
+	Type      Type      `json:"type"`
+	Time      time.Time `json:"time"`
+	CommandID uuid.UUID `json:"command_id"`
+	Data      any       `json:"data,omitempty"`
+}
+
+// Bus fans events out to subscribers. Publishing never blocks: a
+// subscriber that falls behind by more than its buffer is dropped. A nil
+// *Bus discards everything.
+type Bus struct {
+	mu      sync.Mutex
+	nextID  uint64
+	history []Event
+	subs    map[*Subscription]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Publish stamps e with an ID and time and delivers it.
+func (b *Bus) Publish(typ Type, commandID uuid.UUID, data any) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	e := Event{ID: b.nextID, Type: typ, Time: time.Now(), CommandID: commandID, Data: data}
+	b.history = append(b.history, e)
+	if len(b.history) > historySize {
+		b.history = slices.Delete(b.history, 0, len(b.history)-historySize)
+	}
+
+	for sub := range b.subs {
+		select {
+		case sub.ch <- e:
+		default:
+			delete(b.subs, sub)
+			sub.dropped = true
+			close(sub.ch)
+		}
+	}
+}
+
+// Subscribers reports how many subscriptions are open.
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// Subscription receives events on C until Close is called or it is
+// dropped for falling behind, in which case C is closed and Dropped
+// reports true.
+type Subscription struct {
+	C       <-chan Event
+	ch      chan Event
+	bus     *Bus
+	dropped bool
+}
+
+// Subscribe starts delivering events published from now on. Events after
+// lastID still held in history are delivered first, so a client that
+// reconnects with the last ID it saw misses nothing that recent.
+func (b *Bus) Subscribe(buffer int, lastID uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []Event
+	if lastID > 0 {
+		for _, e := range b.history {
+			if e.ID > lastID {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, buffer+len(backlog))
+	for _, e := range backlog {
+		ch <- e
+	}
+	sub := &Subscription{C: ch, ch: ch, bus: b}
+	b.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close stops delivery. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	if _, ok := s.bus.subs[s]; ok {
+		delete(s.bus.subs, s)
+		close(s.ch)
+	}
+}
+
+// Dropped reports whether the bus cut the subscription off for falling
+// behind.
+func (s *Subscription) Dropped() bool {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	return s.dropped
+}
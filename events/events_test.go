@@ -0,0 +1,84 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus_PublishSubscribe(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(4, 0)
+	defer sub.Close()
+
+	id := uuid.New()
+	bus.Publish(RunStarted, id, "data")
+
+	e := <-sub.C
+	assert.Equal(t, uint64(1), e.ID)
+	assert.Equal(t, RunStarted, e.Type)
+	assert.Equal(t, id, e.CommandID)
+	assert.Equal(t, "data", e.Data)
+	assert.False(t, e.Time.IsZero())
+}
+
+func TestBus_ReplaysAfterLastID(t *testing.T) {
+	bus := NewBus()
+	for range 3 {
+		bus.Publish(CommandCreated, uuid.New(), nil)
+	}
+
+	sub := bus.Subscribe(4, 1)
+	defer sub.Close()
+	assert.Equal(t, uint64(2), (<-sub.C).ID)
+	assert.Equal(t, uint64(3), (<-sub.C).ID)
+
+	bus.Publish(CommandDeleted, uuid.New(), nil)
+	assert.Equal(t, uint64(4), (<-sub.C).ID)
+}
+
+func TestBus_DropsSlowSubscriber(t *testing.T) {
+	bus := NewBus()
+	slow := bus.Subscribe(1, 0)
+	fast := bus.Subscribe(4, 0)
+	defer fast.Close()
+
+	bus.Publish(RunStarted, uuid.Nil, nil)
+	bus.Publish(RunExited, uuid.Nil, nil)
+
+	assert.True(t, slow.Dropped())
+	_, ok := <-slow.C
+	require.True(t, ok, "events delivered before the drop are kept")
+	_, ok = <-slow.C
+	assert.False(t, ok)
+	slow.Close()
+
+	assert.False(t, fast.Dropped())
+	assert.Len(t, fast.C, 2)
+}
+
+func TestBus_CloseIsIdempotent(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(1, 0)
+	sub.Close()
+	sub.Close()
+
+	bus.Publish(RunStarted, uuid.Nil, nil)
+	_, ok := <-sub.C
+	assert.False(t, ok)
+}
+
+func TestBus_NilDiscards(t *testing.T) {
+	var bus *Bus
+	assert.NotPanics(t, func() { bus.Publish(RunStarted, uuid.Nil, nil) })
+}
+
+func TestBus_Subscribers(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(1, 0)
+	assert.Equal(t, 1, bus.Subscribers())
+	sub.Close()
+	assert.Equal(t, 0, bus.Subscribers())
+}
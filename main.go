@@ -1,18 +1,102 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"io/fs"
 	"log"
+	"os"
+	"runtime/debug"
+	"strconv"
 
+	"github.com/cloud-gt/ai-sensors/auth"
 	"github.com/cloud-gt/ai-sensors/command"
 	"github.com/cloud-gt/ai-sensors/dashboard"
+	"github.com/cloud-gt/ai-sensors/endpoint"
+	"github.com/cloud-gt/ai-sensors/events"
 	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/policy"
 	"github.com/cloud-gt/ai-sensors/server"
+	"github.com/cloud-gt/ai-sensors/webhook"
 )
 
+// version is set at build time with -ldflags "-X main.version=...".
+var version = "dev"
+
 func main() {
-	store := command.NewStore(command.NewMemoryRepository())
-	mgr := manager.New(store)
-	srv := server.New(store, mgr)
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		os.Exit(runToken(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && cliCommands[os.Args[1]] {
+		os.Exit(runCLI(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	addr := flag.String("addr", endpoint.Default, "listen address: host:port or unix:///path/to.sock")
+	socketMode := flag.String("socket-mode", "0600", "permissions of a Unix socket")
+	tokensPath := flag.String("tokens", auth.DefaultPath(), "API token file; authentication is enforced once it holds a token")
+	cgroupParent := flag.String("cgroup-parent", "", "cgroup v2 directory to place resource-limited commands under (default: own cgroup, usable only at the cgroup root; otherwise limits fall back to rlimits)")
+	configPath := flag.String("config", "", "JSON file with an execution policy and command definitions to load")
+	flag.Parse()
+
+	listenAddr, err := endpoint.Parse(*addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	mode, err := strconv.ParseUint(*socketMode, 8, 32)
+	if err != nil {
+		log.Fatal("invalid -socket-mode: ", err)
+	}
+
+	tokens := auth.NewTokenStore(*tokensPath)
+	if enabled, err := tokens.Enabled(); err != nil {
+		log.Fatal("failed to read token file: ", err)
+	} else if !enabled {
+		log.Printf("WARNING: no API tokens in %s, the API is unauthenticated. Create one with: ai-sensors token create -name NAME -scopes read,control,define", *tokensPath)
+		if listenAddr.Public() {
+			log.Printf("WARNING: %s is reachable from the network, but only loopback clients are served until a token exists", listenAddr)
+		}
+	}
+
+	repo := command.NewMemoryRepository()
+	bus := events.NewBus()
+	storeOpts := []command.Option{command.WithEvents(bus)}
+	mgrOpts := []manager.Option{manager.WithCgroupParent(*cgroupParent), manager.WithEvents(bus)}
+	var hooks []webhook.Hook
+	if *configPath != "" {
+		cfg, err := policy.LoadConfig(*configPath)
+		if err != nil {
+			log.Fatal("failed to load config: ", err)
+		}
+		pol, err := policy.New(cfg.Policy)
+		if err != nil {
+			log.Fatal("failed to load config: ", err)
+		}
+		if err := repo.Save(cfg.Commands); err != nil {
+			log.Fatal("failed to load config: ", err)
+		}
+		if hooks, err = webhook.LoadConfig(*configPath); err != nil {
+			log.Fatal("failed to load config: ", err)
+		}
+		storeOpts = append(storeOpts, command.WithPolicy(pol))
+		mgrOpts = append(mgrOpts, manager.WithPolicy(pol))
+		if pol.ReadOnly() {
+			log.Printf("Command definitions are read-only, loaded %d from %s", len(cfg.Commands), *configPath)
+		}
+	}
+
+	store := command.NewStore(repo, storeOpts...)
+	if err := store.Load(); err != nil {
+		log.Fatal("failed to load commands: ", err)
+	}
+	mgr := manager.New(store, mgrOpts...)
+	srvOpts := []server.Option{server.WithTokens(tokens), server.WithSocketMode(fs.FileMode(mode)), server.WithVersion(buildVersion()), server.WithEvents(bus)}
+	if len(hooks) > 0 {
+		dispatcher := webhook.New(hooks, store)
+		go dispatcher.Run(context.Background(), bus)
+		srvOpts = append(srvOpts, server.WithWebhooks(dispatcher))
+		log.Printf("Sending webhooks to %d endpoint(s)", len(hooks))
+	}
+	srv := server.New(store, mgr, srvOpts...)
 
 	dashFS, err := dashboard.FS()
 	if err != nil {
@@ -20,9 +104,42 @@ func main() {
 	}
 	srv.MountDashboard(dashFS)
 
-	log.Println("Starting server on :3000")
-	log.Println("Dashboard available at http://localhost:3000/dashboard")
-	if err := srv.ListenAndServe(":3000"); err != nil {
+	go func() {
+		results, err := mgr.Autostart(context.Background())
+		if err != nil {
+			log.Println("Autostart failed:", err)
+			return
+		}
+		for _, res := range results {
+			if res.Changed {
+				log.Println("Autostarted command", res.ID)
+			}
+		}
+	}()
+
+	log.Println("Starting server on", listenAddr)
+	if listenAddr.Network == "tcp" {
+		log.Printf("Dashboard available at http://%s/dashboard", listenAddr.Address)
+	}
+	if err := srv.ListenAndServe(listenAddr.String()); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// buildVersion returns version, or the VCS revision the binary was built
+// from when no version was stamped.
+func buildVersion() string {
+	if version != "dev" {
+		return version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return version
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+			return version + "+" + setting.Value[:12]
+		}
+	}
+	return version
+}
@@ -0,0 +1,42 @@
+package manager
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+// Autostart starts every command flagged with autostart, dependencies first.
+func (m *Manager) Autostart(ctx context.Context) ([]BulkResult, error) {
+	commands, err := m.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []uuid.UUID
+	for _, cmd := range commands {
+		if cmd.Autostart {
+			ids = append(ids, cmd.ID)
+		}
+	}
+
+	results := m.startMany(ctx, ids, TriggerAutostart)
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Warn("failed to autostart command", "id", res.ID, "error", res.Err)
+		}
+	}
+
+	return results, nil
+}
+
+// Autostarted reports whether the current instance of a command was launched
+// by Autostart rather than on request.
+func (m *Manager) Autostarted(id uuid.UUID) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inst, ok := m.instances[id]
+	return ok && inst.run.Trigger == TriggerAutostart
+}
@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_AutostartFlaggedCommands(t *testing.T) {
+	store := newTestStore()
+	db, err := store.Create(command.Command{Name: "db", Command: "sleep 60", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	api, err := store.Create(command.Command{
+		Name:      "api",
+		Command:   "sleep 60",
+		WorkDir:   "/tmp",
+		DependsOn: []uuid.UUID{db.ID},
+		Autostart: true,
+	})
+	require.NoError(t, err)
+	manual, err := store.Create(command.Command{Name: "manual", Command: "sleep 60", WorkDir: "/tmp"})
+	require.NoError(t, err)
+
+	m := New(store)
+	defer func() { _ = m.Shutdown(context.Background()) }()
+
+	results, err := m.Autostart(context.Background())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, api.ID, results[0].ID)
+	assert.True(t, results[0].Changed)
+
+	status, err := m.Status(api.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, status)
+	assert.True(t, m.Autostarted(api.ID))
+
+	status, err = m.Status(db.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, status)
+	assert.False(t, m.Autostarted(db.ID))
+
+	_, err = m.Status(manual.ID)
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestManager_ManualRestartClearsAutostarted(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{Name: "watcher", Command: "sleep 60", WorkDir: "/tmp", Autostart: true})
+	require.NoError(t, err)
+
+	m := New(store)
+
+	_, err = m.Autostart(context.Background())
+	require.NoError(t, err)
+	require.True(t, m.Autostarted(cmd.ID))
+
+	require.NoError(t, m.Stop(cmd.ID))
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	defer func() { _ = m.Stop(cmd.ID) }()
+
+	assert.False(t, m.Autostarted(cmd.ID))
+}
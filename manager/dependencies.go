@@ -0,0 +1,65 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/google/uuid"
+)
+
+const dependencyPollInterval = 50 * time.Millisecond
+
+// startDependencies starts every dependency of cmd, depth first, and waits
+// for each one to be running, or ready when it declares readiness checks.
+// The store guarantees the dependency graph is acyclic.
+func (m *Manager) startDependencies(ctx context.Context, cmd command.Command) error {
+	for _, depID := range cmd.DependsOn {
+		if _, err := m.start(ctx, depID, TriggerDependency, nil); err != nil {
+			return fmt.Errorf("starting dependency %s of %s: %w", depID, cmd.Name, err)
+		}
+		if err := m.waitReady(ctx, depID); err != nil {
+			return fmt.Errorf("waiting for dependency %s of %s: %w", depID, cmd.Name, err)
+		}
+	}
+	return nil
+}
+
+func (m *Manager) waitReady(ctx context.Context, id uuid.UUID) error {
+	ticker := time.NewTicker(dependencyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		status, err := m.Status(id)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case StatusRunning, StatusReady:
+			return nil
+		case StatusStopped, StatusUnhealthy:
+			return fmt.Errorf("%w: status is %s", ErrDependencyNotReady, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// stopOrder returns ids with dependents before their dependencies.
+func (m *Manager) stopOrder(ids []uuid.UUID) []uuid.UUID {
+	commands, err := m.store.List()
+	if err != nil {
+		slog.Warn("failed to list commands for stop order", "error", err)
+		return ids
+	}
+	ordered := command.SortByDependencies(commands, ids)
+	slices.Reverse(ordered)
+	return ordered
+}
@@ -0,0 +1,111 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_StartsDependenciesFirst(t *testing.T) {
+	store := newTestStore()
+	db, err := store.Create(command.Command{
+		Name:    "db",
+		Command: "sleep 0.2; echo db-ready; sleep 60",
+		WorkDir: "/tmp",
+		Readiness: &command.Readiness{
+			Checks:   []command.ReadinessCheck{{Type: command.ReadinessLog, Pattern: "db-ready"}},
+			Interval: command.Duration(20 * time.Millisecond),
+		},
+	})
+	require.NoError(t, err)
+	api, err := store.Create(command.Command{
+		Name:      "api",
+		Command:   "sleep 60",
+		WorkDir:   "/tmp",
+		DependsOn: []uuid.UUID{db.ID},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	defer func() { _ = m.Shutdown(context.Background()) }()
+
+	started, err := m.Start(context.Background(), api.ID)
+	require.NoError(t, err)
+	assert.True(t, started)
+
+	dbStatus, err := m.Status(db.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusReady, dbStatus)
+
+	apiStatus, err := m.Status(api.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, apiStatus)
+}
+
+func TestManager_StartFailsWhenDependencyExits(t *testing.T) {
+	store := newTestStore()
+	db, err := store.Create(command.Command{
+		Name:    "db",
+		Command: "exit 1",
+		WorkDir: "/tmp",
+		Readiness: &command.Readiness{
+			Checks: []command.ReadinessCheck{{Type: command.ReadinessLog, Pattern: "never"}},
+		},
+	})
+	require.NoError(t, err)
+	api, err := store.Create(command.Command{
+		Name:      "api",
+		Command:   "sleep 60",
+		WorkDir:   "/tmp",
+		DependsOn: []uuid.UUID{db.ID},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+
+	_, err = m.Start(context.Background(), api.ID)
+
+	assert.ErrorIs(t, err, ErrDependencyNotReady)
+	_, err = m.Status(api.ID)
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestManager_ShutdownStopsDependentsFirst(t *testing.T) {
+	store := newTestStore()
+	db, err := store.Create(command.Command{Name: "db", Command: "sleep 60", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	api, err := store.Create(command.Command{
+		Name:      "api",
+		Command:   "sleep 60",
+		WorkDir:   "/tmp",
+		DependsOn: []uuid.UUID{db.ID},
+	})
+	require.NoError(t, err)
+	web, err := store.Create(command.Command{
+		Name:      "web",
+		Command:   "sleep 60",
+		WorkDir:   "/tmp",
+		DependsOn: []uuid.UUID{api.ID},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+
+	order := m.stopOrder([]uuid.UUID{db.ID, web.ID, api.ID})
+	assert.Equal(t, []uuid.UUID{web.ID, api.ID, db.ID}, order)
+
+	_, err = m.Start(context.Background(), web.ID)
+	require.NoError(t, err)
+	require.NoError(t, m.Shutdown(context.Background()))
+
+	for _, id := range []uuid.UUID{db.ID, api.ID, web.ID} {
+		status, err := m.Status(id)
+		require.NoError(t, err)
+		assert.Equal(t, StatusStopped, status)
+	}
+}
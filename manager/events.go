@@ -0,0 +1,29 @@
+package manager
+
+import (
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/google/uuid"
+)
+
+// WithEvents publishes run, readiness and diagnostic events to bus.
+func WithEvents(bus *events.Bus) Option {
+	return func(m *Manager) {
+		m.events = bus
+	}
+}
+
+// publishRestart reports the run a watch restart began. The run.started
+// event for it has already been published by start.
+func (m *Manager) publishRestart(id uuid.UUID) {
+	m.mu.RLock()
+	inst, exists := m.instances[id]
+	var run Run
+	if exists {
+		run = inst.run
+	}
+	m.mu.RUnlock()
+
+	if exists {
+		m.events.Publish(events.RunRestarted, id, run)
+	}
+}
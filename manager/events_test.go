@@ -0,0 +1,121 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/diagnostics"
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nextEvent returns the next event of one of the given types, skipping
+// others.
+func nextEvent(t *testing.T, sub *events.Subscription, types ...events.Type) events.Event {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e, ok := <-sub.C:
+			require.True(t, ok, "subscription closed")
+			for _, typ := range types {
+				if e.Type == typ {
+					return e
+				}
+			}
+		case <-timeout:
+			require.FailNow(t, "no event", "want %v", types)
+		}
+	}
+}
+
+func TestManager_PublishesRunEvents(t *testing.T) {
+	bus := events.NewBus()
+	store := command.NewStore(command.NewMemoryRepository(), command.WithEvents(bus))
+	sub := bus.Subscribe(32, 0)
+	defer sub.Close()
+
+	cmd, err := store.Create(command.Command{
+		Name:      "build",
+		Command:   "echo './main.go:10:2: undefined: foo'; echo listening; exit 3",
+		WorkDir:   "/tmp",
+		Matchers:  []diagnostics.Definition{{Name: "go"}},
+		Readiness: &command.Readiness{Checks: []command.ReadinessCheck{{Type: command.ReadinessLog, Pattern: "listening"}}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, events.CommandCreated, nextEvent(t, sub, events.CommandCreated).Type)
+
+	m := New(store, WithEvents(bus))
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+
+	started := nextEvent(t, sub, events.RunStarted)
+	assert.Equal(t, cmd.ID, started.CommandID)
+	assert.Equal(t, TriggerManual, started.Data.(Run).Trigger)
+
+	matched := nextEvent(t, sub, events.OutputMatched)
+	assert.Equal(t, "undefined: foo", matched.Data.(diagnostics.Diagnostic).Message)
+
+	exited := nextEvent(t, sub, events.RunExited)
+	run := exited.Data.(Run)
+	require.NotNil(t, run.ExitCode)
+	assert.Equal(t, 3, *run.ExitCode)
+	assert.False(t, run.EndedAt.IsZero())
+}
+
+func TestManager_PublishesReadiness(t *testing.T) {
+	bus := events.NewBus()
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:      "server",
+		Command:   "echo listening; sleep 60",
+		WorkDir:   "/tmp",
+		Readiness: &command.Readiness{Checks: []command.ReadinessCheck{{Type: command.ReadinessLog, Pattern: "listening"}}},
+	})
+	require.NoError(t, err)
+
+	sub := bus.Subscribe(32, 0)
+	defer sub.Close()
+	m := New(store, WithEvents(bus))
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	defer func() { _ = m.Stop(cmd.ID) }()
+
+	ready := nextEvent(t, sub, events.CommandReady)
+	assert.Equal(t, cmd.ID, ready.CommandID)
+}
+
+func TestManager_PublishesWatchRestart(t *testing.T) {
+	dir := t.TempDir()
+	bus := events.NewBus()
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "server",
+		Command: "sleep 60",
+		WorkDir: dir,
+		Watch:   &command.Watch{Debounce: command.Duration(50 * time.Millisecond)},
+	})
+	require.NoError(t, err)
+
+	sub := bus.Subscribe(32, 0)
+	defer sub.Close()
+	m := New(store, WithEvents(bus))
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	defer func() { _ = m.Stop(cmd.ID) }()
+	nextEvent(t, sub, events.RunStarted)
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("a: 1"), 0644))
+
+	assert.Equal(t, events.RunExited, nextEvent(t, sub, events.RunExited, events.RunRestarted).Type)
+	restarted := nextEvent(t, sub, events.RunRestarted)
+	run := restarted.Data.(Run)
+	assert.Equal(t, 2, run.Number)
+	assert.Equal(t, TriggerWatch, run.Trigger)
+}
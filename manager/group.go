@@ -0,0 +1,53 @@
+package manager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/google/uuid"
+)
+
+type BulkResult struct {
+	ID      uuid.UUID
+	Changed bool
+	Err     error
+}
+
+// StartMany starts the given commands in dependency order. A failure does not
+// prevent the remaining commands from being started.
+func (m *Manager) StartMany(ctx context.Context, ids []uuid.UUID) []BulkResult {
+	return m.startMany(ctx, ids, TriggerManual)
+}
+
+func (m *Manager) startMany(ctx context.Context, ids []uuid.UUID, trigger Trigger) []BulkResult {
+	commands, err := m.store.List()
+	if err == nil {
+		ids = command.SortByDependencies(commands, ids)
+	}
+
+	results := make([]BulkResult, 0, len(ids))
+	for _, id := range ids {
+		started, err := m.start(ctx, id, trigger, nil)
+		results = append(results, BulkResult{ID: id, Changed: started, Err: err})
+	}
+	return results
+}
+
+// StopMany stops the given commands, dependents before their dependencies.
+// Commands that were never started are reported as unchanged.
+func (m *Manager) StopMany(ids []uuid.UUID) []BulkResult {
+	ids = m.stopOrder(ids)
+
+	results := make([]BulkResult, 0, len(ids))
+	for _, id := range ids {
+		status, err := m.Status(id)
+		if errors.Is(err, ErrNotRunning) {
+			results = append(results, BulkResult{ID: id})
+			continue
+		}
+		err = m.Stop(id)
+		results = append(results, BulkResult{ID: id, Changed: err == nil && status.Active(), Err: err})
+	}
+	return results
+}
@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultStuckAfter leaves the runner time to escalate from SIGTERM to
+// SIGKILL before a stop counts as stuck.
+const defaultStuckAfter = 15 * time.Second
+
+// WithStuckThreshold sets how long a stop may take before the instance is
+// reported as stuck.
+func WithStuckThreshold(d time.Duration) Option {
+	return func(m *Manager) {
+		if d > 0 {
+			m.stuck = d
+		}
+	}
+}
+
+// Stuck returns the commands whose stop was requested longer ago than the
+// stuck threshold but whose process has still not exited, sorted by ID.
+func (m *Manager) Stuck() []uuid.UUID {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var ids []uuid.UUID
+	for id, inst := range m.instances {
+		if inst.status.Active() && !inst.stopRequested.IsZero() && time.Since(inst.stopRequested) > m.stuck {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return slices.Compare(a[:], b[:]) })
+	return ids
+}
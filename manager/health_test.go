@@ -0,0 +1,54 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_StuckReportsStopsThatDoNotFinish(t *testing.T) {
+	store := newTestStore()
+	stubborn, err := store.Create(command.Command{
+		Name:    "stubborn",
+		Command: `trap "" TERM; echo ready; while true; do sleep 0.05; done`,
+		WorkDir: "/tmp",
+	})
+	require.NoError(t, err)
+	polite, err := store.Create(command.Command{Name: "polite", Command: "sleep 60", WorkDir: "/tmp"})
+	require.NoError(t, err)
+
+	m := New(store, WithStuckThreshold(50*time.Millisecond))
+	for _, id := range []uuid.UUID{stubborn.ID, polite.ID} {
+		_, err := m.Start(context.Background(), id)
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		out, _ := m.Output(stubborn.ID)
+		return len(out) > 0
+	}, 5*time.Second, 10*time.Millisecond, "trap must be installed before stopping")
+
+	assert.Empty(t, m.Stuck())
+
+	require.NoError(t, m.Stop(polite.ID))
+	stopped := make(chan struct{})
+	go func() {
+		_ = m.Stop(stubborn.ID)
+		close(stopped)
+	}()
+
+	require.Eventually(t, func() bool { return len(m.Stuck()) == 1 }, 3*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []uuid.UUID{stubborn.ID}, m.Stuck())
+
+	// The runner escalates to SIGKILL, after which nothing is stuck.
+	select {
+	case <-stopped:
+	case <-time.After(10 * time.Second):
+		t.Fatal("stop did not escalate to SIGKILL")
+	}
+	assert.Empty(t, m.Stuck())
+}
@@ -0,0 +1,19 @@
+package manager
+
+import (
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/runner"
+)
+
+func runnerLimits(l *command.Limits) runner.Limits {
+	if l == nil {
+		return runner.Limits{}
+	}
+	return runner.Limits{
+		Memory:    l.MemoryMB << 20,
+		CPU:       l.CPU,
+		Processes: l.Processes,
+		OpenFiles: l.OpenFiles,
+		Timeout:   l.Timeout.Std(),
+	}
+}
@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_TimeoutRecordedInRun(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "hang",
+		Command: "sleep 60",
+		WorkDir: "/tmp",
+		Limits:  &command.Limits{Timeout: command.Duration(100 * time.Millisecond)},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	run, err := m.Wait(ctx, cmd.ID)
+	require.NoError(t, err)
+
+	assert.Equal(t, runner.TerminationTimeout, run.Termination)
+	assert.Nil(t, run.ExitCode)
+	assert.Contains(t, run.Error, "timeout")
+}
+
+func TestManager_UnenforcedLimitsRecordedInRun(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "forky",
+		Command: "true",
+		WorkDir: "/tmp",
+		Limits:  &command.Limits{Processes: 50},
+	})
+	require.NoError(t, err)
+
+	// A plain directory is not a cgroup, so the limit cannot be enforced.
+	m := New(store, WithCgroupParent(t.TempDir()))
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	run, err := m.Wait(ctx, cmd.ID)
+	require.NoError(t, err)
+
+	assert.Contains(t, run.LimitsError, runner.ErrLimitsUnenforced.Error())
+	require.NotNil(t, run.ExitCode)
+	assert.Equal(t, 0, *run.ExitCode)
+}
@@ -4,16 +4,23 @@ import (
 	"context"
 	"errors"
 	"sync"
+	"time"
 
 	"github.com/cloud-gt/ai-sensors/buffer"
 	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/diagnostics"
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/procstats"
+	"github.com/cloud-gt/ai-sensors/redact"
 	"github.com/cloud-gt/ai-sensors/runner"
+	"github.com/cloud-gt/ai-sensors/testreport"
 	"github.com/google/uuid"
 )
 
 var (
-	ErrCommandNotFound = errors.New("command not found in store")
-	ErrNotRunning      = errors.New("command is not running")
+	ErrCommandNotFound    = errors.New("command not found in store")
+	ErrNotRunning         = errors.New("command is not running")
+	ErrDependencyNotReady = errors.New("dependency did not become ready")
 )
 
 type Status string
@@ -22,15 +29,40 @@ const (
 	StatusNotStarted Status = "not_started"
 	StatusRunning    Status = "running"
 	StatusStopped    Status = "stopped"
+	StatusStarting   Status = "starting"
+	StatusReady      Status = "ready"
+	StatusUnhealthy  Status = "unhealthy"
 )
 
-const defaultBufferCapacity = 1000
+// Active reports whether the status describes a live process.
+func (s Status) Active() bool {
+	switch s {
+	case StatusRunning, StatusStarting, StatusReady, StatusUnhealthy:
+		return true
+	}
+	return false
+}
+
+const (
+	defaultBufferCapacity = 1000
+	// followBuffer is how many lines a follower may lag behind before it
+	// is cut off.
+	followBuffer = 1024
+)
 
 type Manager struct {
 	store     *command.Store
+	policy    Policy
 	bufferCap int
+	cgroup    string
+	sampling  time.Duration
+	stuck     time.Duration
+	events    *events.Bus
 	mu        sync.RWMutex
 	instances map[uuid.UUID]*Instance
+	history   map[uuid.UUID][]Run
+	watchers  map[uuid.UUID]*fileWatch
+	stats     map[uuid.UUID]*CommandStats
 }
 
 type Instance struct {
@@ -39,10 +71,31 @@ type Instance struct {
 	buffer  *buffer.RingBuffer
 	status  Status
 	cancel  context.CancelFunc
+	done    chan struct{}
+	run     Run
+	tests   testreport.Parser
+	diags   *diagnostics.Collector
+	sampler *procstats.Sampler
+
+	// stopRequested is when Stop was first called on a live process.
+	stopRequested time.Time
+}
+
+// Policy decides whether a command may be started.
+type Policy interface {
+	AllowRun(cmd command.Command) error
 }
 
 type Option func(*Manager)
 
+// WithPolicy checks every start, including restarts, dependency starts and
+// autostarts, against p.
+func WithPolicy(p Policy) Option {
+	return func(m *Manager) {
+		m.policy = p
+	}
+}
+
 func WithBufferCapacity(cap int) Option {
 	return func(m *Manager) {
 		if cap > 0 {
@@ -51,11 +104,24 @@ func WithBufferCapacity(cap int) Option {
 	}
 }
 
+// WithCgroupParent places limited commands under the given cgroup v2
+// directory instead of the manager's own cgroup.
+func WithCgroupParent(dir string) Option {
+	return func(m *Manager) {
+		m.cgroup = dir
+	}
+}
+
 func New(store *command.Store, opts ...Option) *Manager {
 	m := &Manager{
 		store:     store,
 		bufferCap: defaultBufferCapacity,
+		sampling:  procstats.DefaultInterval,
+		stuck:     defaultStuckAfter,
 		instances: make(map[uuid.UUID]*Instance),
+		history:   make(map[uuid.UUID][]Run),
+		watchers:  make(map[uuid.UUID]*fileWatch),
+		stats:     make(map[uuid.UUID]*CommandStats),
 	}
 
 	for _, opt := range opts {
@@ -66,6 +132,10 @@ func New(store *command.Store, opts ...Option) *Manager {
 }
 
 func (m *Manager) Start(ctx context.Context, id uuid.UUID) (bool, error) {
+	return m.start(ctx, id, TriggerManual, nil)
+}
+
+func (m *Manager) start(ctx context.Context, id uuid.UUID, trigger Trigger, changes []string) (bool, error) {
 	cmd, err := m.store.Get(id)
 	if err != nil {
 		if errors.Is(err, command.ErrNotFound) {
@@ -74,25 +144,62 @@ func (m *Manager) Start(ctx context.Context, id uuid.UUID) (bool, error) {
 		return false, err
 	}
 
+	if m.policy != nil {
+		if err := m.policy.AllowRun(cmd); err != nil {
+			return false, err
+		}
+	}
+
+	if err := m.startDependencies(ctx, cmd); err != nil {
+		return false, err
+	}
+
 	m.mu.Lock()
 	if inst, exists := m.instances[id]; exists {
-		if inst.status == StatusRunning {
+		if inst.status.Active() {
 			m.mu.Unlock()
 			return false, nil
 		}
 	}
 
-	buf, err := buffer.New(m.bufferCap)
+	var bufOpts []buffer.Option
+	if cmd.Dedup {
+		bufOpts = append(bufOpts, buffer.WithDedup())
+	}
+	buf, err := buffer.New(m.bufferCap, bufOpts...)
+	if err != nil {
+		m.mu.Unlock()
+		return false, err
+	}
+	tests := newTestParser(cmd)
+	buf.OnLine(tests.Feed)
+	diags, err := diagnostics.NewCollector(cmd.Matchers)
+	if err != nil {
+		m.mu.Unlock()
+		return false, err
+	}
+	diags.OnNew(func(d diagnostics.Diagnostic) {
+		m.events.Publish(events.OutputMatched, id, d)
+	})
+	buf.OnLine(diags.Feed)
+
+	// Output passes through redaction before it reaches the buffer, so
+	// secrets are never stored or seen by observers.
+	redactor, err := redact.New(cmd.Redact, cmd.SecretValues())
 	if err != nil {
 		m.mu.Unlock()
 		return false, err
 	}
+	output := redactor.Writer(buf)
 
 	r, err := runner.New(runner.Config{
-		Command: "sh",
-		Args:    []string{"-c", cmd.Command},
-		Output:  buf,
-		Dir:     cmd.WorkDir,
+		Command:      "sh",
+		Args:         []string{"-c", cmd.Command},
+		Output:       output,
+		Dir:          cmd.WorkDir,
+		Env:          cmd.Environ(),
+		Limits:       runnerLimits(cmd.Limits),
+		CgroupParent: m.cgroup,
 	})
 	if err != nil {
 		m.mu.Unlock()
@@ -101,44 +208,94 @@ func (m *Manager) Start(ctx context.Context, id uuid.UUID) (bool, error) {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
+	status := StatusRunning
+	if cmd.Readiness != nil {
+		status = StatusStarting
+	}
+
+	run := m.newRun(id, trigger, changes)
 	inst := &Instance{
 		command: cmd,
 		runner:  r,
 		buffer:  buf,
-		status:  StatusRunning,
+		status:  status,
 		cancel:  cancel,
+		done:    make(chan struct{}),
+		run:     run,
+		tests:   tests,
+		diags:   diags,
+		sampler: procstats.NewSampler(r.Pid, m.sampling, procstats.DefaultCapacity),
 	}
 	m.instances[id] = inst
+	m.recordStart(id)
 	m.mu.Unlock()
+	m.events.Publish(events.RunStarted, id, run)
+
+	if procstats.Supported() {
+		go inst.sampler.Run(ctx)
+	}
+
+	if cmd.Readiness != nil {
+		go m.watchReadiness(ctx, inst, cmd.Readiness)
+	}
+	if cmd.Watch != nil && trigger != TriggerWatch {
+		m.startWatcher(cmd)
+	}
 
 	go func() {
-		_ = r.Start(ctx)
+		err := r.Start(ctx)
+		cancel()
+		_ = output.Flush()
+		buf.Close()
 
-		m.mu.Lock()
-		if m.instances[id] != nil {
-			m.instances[id].status = StatusStopped
+		if artifact, ok := tests.(*testreport.Artifact); ok {
+			artifact.Set(loadArtifact(cmd, run.StartedAt))
 		}
+
+		m.mu.Lock()
+		inst.status = StatusStopped
+		m.finishRun(id, inst, err)
+		finished := inst.run
 		m.mu.Unlock()
+		m.events.Publish(events.RunExited, id, finished)
+		close(inst.done)
 	}()
 
 	return true, nil
 }
 
+// Stop stops the running process of a command and ends its file watch, if any.
 func (m *Manager) Stop(id uuid.UUID) error {
+	m.stopWatcher(id)
+	return m.stopInstance(id)
+}
+
+func (m *Manager) stopInstance(id uuid.UUID) error {
 	m.mu.RLock()
 	inst, exists := m.instances[id]
+	var status Status
+	if exists {
+		status = inst.status
+	}
 	m.mu.RUnlock()
 
 	if !exists {
 		return ErrNotRunning
 	}
 
-	if inst.status == StatusStopped {
+	if status == StatusStopped {
 		return nil
 	}
 
+	m.mu.Lock()
+	if inst.stopRequested.IsZero() {
+		inst.stopRequested = time.Now()
+	}
+	m.mu.Unlock()
+
 	inst.cancel()
 	_ = inst.runner.Stop()
+	<-inst.done
 
 	return nil
 }
@@ -167,11 +324,69 @@ func (m *Manager) OutputLastN(id uuid.UUID, n int) ([]string, error) {
 	return inst.buffer.LastN(n), nil
 }
 
-func (m *Manager) Status(id uuid.UUID) (Status, error) {
+// OutputEntries returns the buffered output with repeat counts and
+// timestamps.
+func (m *Manager) OutputEntries(id uuid.UUID) ([]buffer.Entry, error) {
 	m.mu.RLock()
 	inst, exists := m.instances[id]
 	m.mu.RUnlock()
 
+	if !exists {
+		return nil, ErrNotRunning
+	}
+
+	return inst.buffer.Entries(), nil
+}
+
+// FollowOutput returns the last n lines of the current run's output and a
+// channel receiving the lines written after them. The channel is closed
+// when the run ends, when stop is called or if the reader falls behind.
+func (m *Manager) FollowOutput(id uuid.UUID, n int) (lines []string, ch <-chan string, stop func(), err error) {
+	m.mu.RLock()
+	inst, exists := m.instances[id]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, nil, nil, ErrNotRunning
+	}
+
+	lines, ch, stop = inst.buffer.Follow(n, followBuffer)
+	return lines, ch, stop, nil
+}
+
+// TestReport returns the test results parsed from the output of the current
+// or last run of a command.
+func (m *Manager) TestReport(id uuid.UUID) (testreport.Report, error) {
+	m.mu.RLock()
+	inst, exists := m.instances[id]
+	m.mu.RUnlock()
+
+	if !exists {
+		return testreport.Report{}, ErrNotRunning
+	}
+
+	return inst.tests.Report(), nil
+}
+
+// Diagnostics returns the problems extracted by the command's matchers from
+// the output of its current or last run, optionally filtered by severity.
+func (m *Manager) Diagnostics(id uuid.UUID, severity diagnostics.Severity) ([]diagnostics.Diagnostic, error) {
+	m.mu.RLock()
+	inst, exists := m.instances[id]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrNotRunning
+	}
+
+	return inst.diags.Diagnostics(severity), nil
+}
+
+func (m *Manager) Status(id uuid.UUID) (Status, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inst, exists := m.instances[id]
 	if !exists {
 		return StatusNotStarted, ErrNotRunning
 	}
@@ -187,6 +402,8 @@ func (m *Manager) Shutdown(ctx context.Context) error {
 	}
 	m.mu.RUnlock()
 
+	ids = m.stopOrder(ids)
+
 	done := make(chan struct{})
 	go func() {
 		for _, id := range ids {
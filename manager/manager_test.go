@@ -499,3 +499,112 @@ func TestManager_ShutdownRespectsContextCancellation(t *testing.T) {
 
 	assert.ErrorIs(t, err, context.Canceled)
 }
+
+func TestManager_RunsRecordExitCode(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "failing",
+		Command: "exit 3",
+		WorkDir: "/tmp",
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+
+	_, err = m.Runs(cmd.ID)
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	for range 2 {
+		_, err = m.Start(context.Background(), cmd.ID)
+		require.NoError(t, err)
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	runs, err := m.Runs(cmd.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	for i, run := range runs {
+		assert.Equal(t, i+1, run.Number)
+		assert.Equal(t, TriggerManual, run.Trigger)
+		require.NotNil(t, run.ExitCode)
+		assert.Equal(t, 3, *run.ExitCode)
+		assert.False(t, run.EndedAt.IsZero())
+	}
+}
+
+func TestManager_RunsRecordStop(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "dev",
+		Command: "trap 'exit 1' TERM; while true; do sleep 0.05; done",
+		WorkDir: "/tmp",
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	require.NoError(t, m.Stop(cmd.ID))
+
+	runs, err := m.Runs(cmd.ID)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.True(t, runs[0].Stopped)
+	require.NotNil(t, runs[0].ExitCode)
+	assert.Equal(t, 1, *runs[0].ExitCode)
+
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	run, _ := m.Wait(ctx, cmd.ID)
+	assert.False(t, run.Stopped)
+	require.NoError(t, m.Stop(cmd.ID))
+}
+
+func TestManager_WaitReturnsFinishedRun(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "build",
+		Command: "sleep 0.1; exit 1",
+		WorkDir: "/tmp",
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+
+	_, err = m.Wait(context.Background(), cmd.ID)
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+
+	run, err := m.Wait(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	require.NotNil(t, run.ExitCode)
+	assert.Equal(t, 1, *run.ExitCode)
+	assert.GreaterOrEqual(t, run.Duration(), 100*time.Millisecond)
+}
+
+func TestManager_WaitRespectsContext(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "sleep-cmd",
+		Command: "sleep 60",
+		WorkDir: "/tmp",
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	defer func() { _ = m.Stop(cmd.ID) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	run, err := m.Wait(ctx, cmd.ID)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, run.ExitCode)
+}
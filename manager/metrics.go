@@ -0,0 +1,35 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/procstats"
+	"github.com/google/uuid"
+)
+
+// WithSampleInterval sets how often process metrics are sampled.
+func WithSampleInterval(d time.Duration) Option {
+	return func(m *Manager) {
+		if d > 0 {
+			m.sampling = d
+		}
+	}
+}
+
+// Metrics returns the latest resource snapshot of a command's process tree
+// and the samples recorded after since. Samples of the last run stay
+// available after it exits; latest is nil until the first sample.
+func (m *Manager) Metrics(id uuid.UUID, since time.Time) (*procstats.Snapshot, []procstats.Sample, error) {
+	if !procstats.Supported() {
+		return nil, nil, procstats.ErrUnsupported
+	}
+
+	m.mu.RLock()
+	inst, exists := m.instances[id]
+	m.mu.RUnlock()
+
+	if !exists {
+		return nil, nil, ErrNotRunning
+	}
+	return inst.sampler.Latest(), inst.sampler.Samples(since), nil
+}
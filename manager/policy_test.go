@@ -0,0 +1,40 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/policy"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_PolicyDeniesStart(t *testing.T) {
+	store := newTestStore()
+	allowed, err := store.Create(command.Command{Name: "allowed", Command: "echo ok", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	denied, err := store.Create(command.Command{Name: "denied", Command: "curl example.com", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	dependent, err := store.Create(command.Command{
+		Name: "dependent", Command: "echo dep", WorkDir: "/tmp", DependsOn: []uuid.UUID{denied.ID},
+	})
+	require.NoError(t, err)
+
+	p, err := policy.New(policy.Rules{Executables: []string{"sleep"}, Patterns: []string{`^echo \w+$`}})
+	require.NoError(t, err)
+	m := New(store, WithPolicy(p))
+
+	started, err := m.Start(context.Background(), allowed.ID)
+	require.NoError(t, err)
+	assert.True(t, started)
+
+	_, err = m.Start(context.Background(), denied.ID)
+	assert.ErrorIs(t, err, policy.ErrDenied)
+	status, _ := m.Status(denied.ID)
+	assert.Equal(t, StatusNotStarted, status)
+
+	_, err = m.Start(context.Background(), dependent.ID)
+	assert.ErrorIs(t, err, policy.ErrDenied, "dependencies are checked too")
+}
@@ -0,0 +1,175 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/buffer"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/events"
+)
+
+const (
+	defaultReadinessInterval = 500 * time.Millisecond
+	defaultReadinessTimeout  = 30 * time.Second
+	probeTimeout             = 2 * time.Second
+)
+
+// watchReadiness evaluates the readiness checks of an instance until its
+// context is cancelled. The instance is marked unhealthy when it has not
+// become ready before the timeout, or when a check fails after it was ready.
+func (m *Manager) watchReadiness(ctx context.Context, inst *Instance, spec *command.Readiness) {
+	interval := spec.Interval.Std()
+	if interval <= 0 {
+		interval = defaultReadinessInterval
+	}
+	timeout := spec.Timeout.Std()
+	if timeout <= 0 {
+		timeout = defaultReadinessTimeout
+	}
+
+	p, err := newProber(spec.Checks, inst.buffer)
+	if err != nil {
+		slog.Warn("readiness checks not started", "command", inst.command.Name, "error", err)
+		m.setProbeStatus(inst, StatusUnhealthy)
+		return
+	}
+	deadline := time.Now().Add(timeout)
+	everReady := false
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		ready := p.probe(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case ready:
+			everReady = true
+			m.setProbeStatus(inst, StatusReady)
+		case everReady || time.Now().After(deadline):
+			m.setProbeStatus(inst, StatusUnhealthy)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Manager) setProbeStatus(inst *Instance, status Status) {
+	m.mu.Lock()
+	if !inst.status.Active() || inst.status == status {
+		m.mu.Unlock()
+		return
+	}
+	inst.status = status
+	m.mu.Unlock()
+
+	switch status {
+	case StatusReady:
+		m.events.Publish(events.CommandReady, inst.command.ID, nil)
+	case StatusUnhealthy:
+		m.events.Publish(events.CommandUnhealthy, inst.command.ID, nil)
+	}
+}
+
+type prober struct {
+	checks   []command.ReadinessCheck
+	patterns []*regexp.Regexp
+	matched  []bool
+	buffer   *buffer.RingBuffer
+	client   *http.Client
+}
+
+func newProber(checks []command.ReadinessCheck, buf *buffer.RingBuffer) (*prober, error) {
+	p := &prober{
+		checks:   checks,
+		patterns: make([]*regexp.Regexp, len(checks)),
+		matched:  make([]bool, len(checks)),
+		buffer:   buf,
+		client:   &http.Client{Timeout: probeTimeout},
+	}
+	for i, c := range checks {
+		if c.Type == command.ReadinessLog {
+			re, err := regexp.Compile(c.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("log check pattern: %w", err)
+			}
+			p.patterns[i] = re
+		}
+	}
+	return p, nil
+}
+
+func (p *prober) probe(ctx context.Context) bool {
+	ready := true
+	for i, c := range p.checks {
+		var err error
+		switch c.Type {
+		case command.ReadinessTCP:
+			err = probeTCP(ctx, c.Address)
+		case command.ReadinessHTTP:
+			err = p.probeHTTP(ctx, c.URL)
+		case command.ReadinessLog:
+			err = p.probeLog(i)
+		default:
+			err = fmt.Errorf("unknown readiness check type %q", c.Type)
+		}
+		if err != nil {
+			slog.Debug("readiness check failed", "type", c.Type, "error", err)
+			ready = false
+		}
+	}
+	return ready
+}
+
+func probeTCP(ctx context.Context, addr string) error {
+	d := net.Dialer{Timeout: probeTimeout}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func (p *prober) probeHTTP(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// probeLog is sticky: once the pattern has appeared in the output the check
+// keeps passing, even after the line has been evicted from the buffer.
+func (p *prober) probeLog(i int) error {
+	if p.matched[i] {
+		return nil
+	}
+	for _, line := range p.buffer.Lines() {
+		if p.patterns[i].MatchString(line) {
+			p.matched[i] = true
+			return nil
+		}
+	}
+	return fmt.Errorf("pattern %q not found in output", p.checks[i].Pattern)
+}
@@ -0,0 +1,109 @@
+package manager
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_ReadinessLogPatternBecomesReady(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "dev-server",
+		Command: "sleep 0.2; echo 'listening on :8080'; sleep 60",
+		WorkDir: "/tmp",
+		Readiness: &command.Readiness{
+			Checks:   []command.ReadinessCheck{{Type: command.ReadinessLog, Pattern: `listening on :\d+`}},
+			Interval: command.Duration(50 * time.Millisecond),
+		},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	defer func() { _ = m.Stop(cmd.ID) }()
+
+	status, err := m.Status(cmd.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusStarting, status)
+
+	assert.Eventually(t, func() bool {
+		status, _ := m.Status(cmd.ID)
+		return status == StatusReady
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestManager_ReadinessHTTPAndTCPChecks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "api",
+		Command: "sleep 60",
+		WorkDir: "/tmp",
+		Readiness: &command.Readiness{
+			Checks: []command.ReadinessCheck{
+				{Type: command.ReadinessHTTP, URL: srv.URL},
+				{Type: command.ReadinessTCP, Address: srv.Listener.Addr().String()},
+			},
+			Interval: command.Duration(50 * time.Millisecond),
+		},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	defer func() { _ = m.Stop(cmd.ID) }()
+
+	assert.Eventually(t, func() bool {
+		status, _ := m.Status(cmd.ID)
+		return status == StatusReady
+	}, 2*time.Second, 20*time.Millisecond)
+}
+
+func TestManager_ReadinessTimeoutMarksUnhealthy(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "never-ready",
+		Command: "sleep 60",
+		WorkDir: "/tmp",
+		Readiness: &command.Readiness{
+			Checks:   []command.ReadinessCheck{{Type: command.ReadinessTCP, Address: addr}},
+			Interval: command.Duration(50 * time.Millisecond),
+			Timeout:  command.Duration(200 * time.Millisecond),
+		},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		status, _ := m.Status(cmd.ID)
+		return status == StatusUnhealthy
+	}, 2*time.Second, 20*time.Millisecond)
+
+	require.NoError(t, m.Stop(cmd.ID))
+
+	status, err := m.Status(cmd.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusStopped, status)
+}
@@ -0,0 +1,82 @@
+package manager
+
+import (
+	"errors"
+
+	"github.com/cloud-gt/ai-sensors/outputdiff"
+	"github.com/google/uuid"
+)
+
+var ErrRunNotFound = errors.New("run not found")
+
+// diffContext is the number of unchanged lines kept around each change.
+const diffContext = 3
+
+type RunDiff struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	outputdiff.Result
+}
+
+// RunOutput returns the output of a run of a command. A finished run returns
+// what it had captured when it ended; the current run is read live.
+func (m *Manager) RunOutput(id uuid.UUID, number int) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.runOutput(id, number)
+}
+
+// runOutput must be called with m.mu held.
+func (m *Manager) runOutput(id uuid.UUID, number int) ([]string, error) {
+	inst, exists := m.instances[id]
+	if !exists {
+		return nil, ErrNotRunning
+	}
+	if inst.status.Active() && inst.run.Number == number {
+		return inst.buffer.Lines(), nil
+	}
+	for _, run := range m.history[id] {
+		if run.Number == number {
+			return run.output, nil
+		}
+	}
+	return nil, ErrRunNotFound
+}
+
+// DiffRuns compares the output of two runs of a command after applying the
+// command's normalization. A zero to selects the latest run and a zero from
+// the run before to.
+func (m *Manager) DiffRuns(id uuid.UUID, from, to int) (RunDiff, error) {
+	cmd, err := m.store.Get(id)
+	if err != nil {
+		return RunDiff{}, ErrCommandNotFound
+	}
+	norm, err := outputdiff.NewNormalizer(cmd.Normalize)
+	if err != nil {
+		return RunDiff{}, err
+	}
+
+	m.mu.RLock()
+	if inst, exists := m.instances[id]; exists && to == 0 {
+		to = inst.run.Number
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	fromLines, err := m.runOutput(id, from)
+	if err != nil {
+		m.mu.RUnlock()
+		return RunDiff{}, err
+	}
+	toLines, err := m.runOutput(id, to)
+	m.mu.RUnlock()
+	if err != nil {
+		return RunDiff{}, err
+	}
+
+	return RunDiff{
+		From:   from,
+		To:     to,
+		Result: outputdiff.Compute(fromLines, toLines, norm, diffContext),
+	}, nil
+}
@@ -0,0 +1,106 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/outputdiff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func runToCompletion(t *testing.T, m *Manager, cmd command.Command) {
+	t.Helper()
+	_, err := m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	_, err = m.Wait(context.Background(), cmd.ID)
+	require.NoError(t, err)
+}
+
+func TestManager_RunOutputIsKeptPerRun(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "counter",
+		Command: `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; echo "run $n"`,
+		WorkDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	runToCompletion(t, m, cmd)
+	runToCompletion(t, m, cmd)
+
+	first, err := m.RunOutput(cmd.ID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run 1"}, first)
+
+	second, err := m.RunOutput(cmd.ID, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"run 2"}, second)
+
+	_, err = m.RunOutput(cmd.ID, 3)
+	assert.ErrorIs(t, err, ErrRunNotFound)
+}
+
+func TestManager_DiffRuns(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "counter",
+		Command: `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; echo "started at $(date +%H:%M:%S.%N)"; echo "result $n"; echo done`,
+		WorkDir: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	runToCompletion(t, m, cmd)
+	runToCompletion(t, m, cmd)
+
+	diff, err := m.DiffRuns(cmd.ID, 0, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, diff.From)
+	assert.Equal(t, 2, diff.To)
+	assert.Equal(t, 1, diff.Added)
+	assert.Equal(t, 1, diff.Removed)
+	require.Len(t, diff.Hunks, 1)
+	assert.Contains(t, diff.Hunks[0].Lines, outputdiff.Line{Op: outputdiff.OpDelete, Text: "result 1"})
+	assert.Contains(t, diff.Hunks[0].Lines, outputdiff.Line{Op: outputdiff.OpInsert, Text: "result 2"})
+}
+
+func TestManager_DiffRunsWithoutNormalization(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "clock",
+		Command: `echo "at $(date +%H:%M:%S.%N)"`,
+		WorkDir: "/tmp",
+		Normalize: &outputdiff.Normalization{
+			Disable: []outputdiff.Rule{outputdiff.RuleTimestamps},
+		},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	runToCompletion(t, m, cmd)
+	runToCompletion(t, m, cmd)
+
+	diff, err := m.DiffRuns(cmd.ID, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, diff.Added)
+}
+
+func TestManager_DiffRunsUnknownRun(t *testing.T) {
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{Name: "once", Command: "echo hi", WorkDir: "/tmp"})
+	require.NoError(t, err)
+
+	m := New(store)
+
+	_, err = m.DiffRuns(cmd.ID, 0, 0)
+	assert.ErrorIs(t, err, ErrNotRunning)
+
+	runToCompletion(t, m, cmd)
+
+	_, err = m.DiffRuns(cmd.ID, 0, 0)
+	assert.ErrorIs(t, err, ErrRunNotFound)
+}
@@ -0,0 +1,149 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/runner"
+	"github.com/cloud-gt/ai-sensors/testreport"
+	"github.com/google/uuid"
+)
+
+const maxRunHistory = 50
+
+type Trigger string
+
+const (
+	TriggerManual     Trigger = "manual"
+	TriggerAutostart  Trigger = "autostart"
+	TriggerDependency Trigger = "dependency"
+	TriggerWatch      Trigger = "watch"
+)
+
+// Run describes one execution of a command.
+type Run struct {
+	Number    int       `json:"number"`
+	Trigger   Trigger   `json:"trigger"`
+	Changes   []string  `json:"changes,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at,omitzero"`
+	ExitCode  *int      `json:"exit_code,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	// Stopped is set when the run ended because it was asked to stop, by
+	// Stop, a restart or shutdown, whatever its exit code.
+	Stopped bool `json:"stopped,omitempty"`
+
+	// Termination names the resource limit that ended the run, if any.
+	Termination runner.Termination `json:"termination,omitempty"`
+
+	// LimitsError explains why the memory, CPU or process limits of the
+	// command were not enforced for this run, if they were not.
+	LimitsError string `json:"limits_error,omitempty"`
+
+	// Tests summarises the test results reported by the run, if any.
+	Tests *testreport.Summary `json:"tests,omitempty"`
+
+	// output is the buffer content captured when the run ended.
+	output []string
+}
+
+// Duration returns how long the run lasted, or has lasted so far.
+func (r Run) Duration() time.Duration {
+	if r.EndedAt.IsZero() {
+		return time.Since(r.StartedAt)
+	}
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+// newRun must be called with m.mu held.
+func (m *Manager) newRun(id uuid.UUID, trigger Trigger, changes []string) Run {
+	number := 1
+	if inst, ok := m.instances[id]; ok {
+		number = inst.run.Number + 1
+	}
+	return Run{
+		Number:    number,
+		Trigger:   trigger,
+		Changes:   changes,
+		StartedAt: time.Now(),
+	}
+}
+
+// finishRun must be called with m.mu held.
+func (m *Manager) finishRun(id uuid.UUID, inst *Instance, err error) {
+	inst.run.EndedAt = time.Now()
+	inst.run.Stopped = !inst.stopRequested.IsZero()
+	inst.run.output = inst.buffer.Lines()
+	inst.run = inst.currentRun()
+	inst.run.Termination = inst.runner.Termination()
+	if code := inst.runner.ExitCode(); code >= 0 {
+		inst.run.ExitCode = &code
+	} else if err != nil && !errors.Is(err, context.Canceled) {
+		inst.run.Error = err.Error()
+	}
+	if summary := inst.tests.Report().Summary; summary.Total > 0 {
+		inst.run.Tests = &summary
+	}
+	m.recordEnd(id, inst, err)
+
+	runs := append(m.history[id], inst.run)
+	if len(runs) > maxRunHistory {
+		runs = runs[len(runs)-maxRunHistory:]
+	}
+	m.history[id] = runs
+}
+
+// currentRun returns the run with what the runner has reported since it
+// started. It must be called with m.mu held.
+func (inst *Instance) currentRun() Run {
+	run := inst.run
+	if err := inst.runner.LimitsError(); err != nil {
+		run.LimitsError = err.Error()
+	}
+	return run
+}
+
+// Runs returns the executions of a command, oldest first, including the
+// current one while it is still running.
+func (m *Manager) Runs(id uuid.UUID) ([]Run, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	inst, exists := m.instances[id]
+	if !exists {
+		return nil, ErrNotRunning
+	}
+
+	runs := make([]Run, len(m.history[id]), len(m.history[id])+1)
+	copy(runs, m.history[id])
+	if inst.status.Active() {
+		runs = append(runs, inst.currentRun())
+	}
+	return runs, nil
+}
+
+// Wait blocks until the current instance of a command exits or ctx is done,
+// and returns its run. On ctx expiry the run is returned as it stands with the
+// context error; the process keeps running.
+func (m *Manager) Wait(ctx context.Context, id uuid.UUID) (Run, error) {
+	m.mu.RLock()
+	inst, exists := m.instances[id]
+	m.mu.RUnlock()
+
+	if !exists {
+		return Run{}, ErrNotRunning
+	}
+
+	select {
+	case <-inst.done:
+	case <-ctx.Done():
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return inst.currentRun(), ctx.Err()
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return inst.run, nil
+}
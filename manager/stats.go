@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloud-gt/ai-sensors/buffer"
+	"github.com/google/uuid"
+)
+
+// CommandStats are the counters of one command since the manager started.
+type CommandStats struct {
+	Status Status
+	Starts uint64
+	// Stops counts runs that were stopped or exited with status 0.
+	Stops uint64
+	// Crashes counts runs that failed on their own: a non-zero exit, a
+	// signal or a resource limit.
+	Crashes uint64
+	// Output sums the buffer counters of every run; Bytes is what the
+	// latest run's buffer holds.
+	Output buffer.Stats
+}
+
+// recordStart must be called with m.mu held.
+func (m *Manager) recordStart(id uuid.UUID) {
+	m.statsFor(id).Starts++
+}
+
+// recordEnd must be called with m.mu held.
+func (m *Manager) recordEnd(id uuid.UUID, inst *Instance, err error) {
+	s := m.statsFor(id)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		s.Crashes++
+	} else {
+		s.Stops++
+	}
+	out := inst.buffer.Stats()
+	s.Output.Lines += out.Lines
+	s.Output.Dropped += out.Dropped
+}
+
+func (m *Manager) statsFor(id uuid.UUID) *CommandStats {
+	s, ok := m.stats[id]
+	if !ok {
+		s = &CommandStats{}
+		m.stats[id] = s
+	}
+	return s
+}
+
+// Stats returns the counters of every command that has been started.
+func (m *Manager) Stats() map[uuid.UUID]CommandStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[uuid.UUID]CommandStats, len(m.stats))
+	for id, s := range m.stats {
+		stats := *s
+		if inst, ok := m.instances[id]; ok {
+			stats.Status = inst.status
+			out := inst.buffer.Stats()
+			stats.Output.Bytes = out.Bytes
+			if inst.status.Active() {
+				stats.Output.Lines += out.Lines
+				stats.Output.Dropped += out.Dropped
+			}
+		}
+		result[id] = stats
+	}
+	return result
+}
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/testreport"
+)
+
+// newTestParser returns the parser for a command's test results source.
+// Commands without one are parsed as go test output.
+func newTestParser(cmd command.Command) testreport.Parser {
+	if cmd.Tests == nil {
+		return testreport.NewGoTestParser()
+	}
+	switch cmd.Tests.Format {
+	case command.TestFormatTAP:
+		return testreport.NewTAPParser()
+	case command.TestFormatJUnit:
+		return testreport.NewArtifact(testreport.FormatJUnit)
+	default:
+		return testreport.NewGoTestParser()
+	}
+}
+
+// loadArtifact reads the results artifact written by a run that started at
+// startedAt. A file older than the run is left over from a previous one and
+// is reported as an error rather than shown as current results.
+func loadArtifact(cmd command.Command, startedAt time.Time) testreport.Report {
+	path := cmd.Tests.ArtifactPath(cmd.WorkDir)
+	report := testreport.Report{Format: testreport.FormatJUnit, Packages: []testreport.Package{}}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		report.Error = fmt.Sprintf("read artifact: %v", err)
+		return report
+	}
+	if info.ModTime().Before(startedAt.Truncate(time.Second)) {
+		report.Error = fmt.Sprintf("artifact %s was not written by this run", path)
+		return report
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		report.Error = fmt.Sprintf("read artifact: %v", err)
+		return report
+	}
+	defer f.Close()
+
+	parsed, err := testreport.ParseJUnit(f)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	return parsed
+}
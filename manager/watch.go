@@ -0,0 +1,108 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/watcher"
+	"github.com/google/uuid"
+)
+
+// startWatcher re-executes cmd whenever files matching its watch rules change.
+// The watcher lives until Stop is called for the command.
+func (m *Manager) startWatcher(cmd command.Command) {
+	w, err := watcher.New(watcher.Config{
+		Root:     cmd.WorkDir,
+		Include:  cmd.Watch.Include,
+		Exclude:  cmd.Watch.Exclude,
+		Debounce: cmd.Watch.Debounce.Std(),
+	})
+	if err != nil {
+		slog.Warn("failed to create file watcher", "command", cmd.Name, "error", err)
+		return
+	}
+
+	m.mu.Lock()
+	if _, exists := m.watchers[cmd.ID]; exists {
+		m.mu.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	fw := &fileWatch{cancel: cancel}
+	m.watchers[cmd.ID] = fw
+	m.mu.Unlock()
+
+	mode := cmd.Watch.EffectiveMode()
+	go func() {
+		err := w.Run(ctx, func(paths []string) {
+			m.onWatchChange(ctx, cmd.ID, mode, paths)
+		})
+		if err != nil {
+			slog.Warn("file watcher stopped", "command", cmd.Name, "error", err)
+		}
+
+		m.mu.Lock()
+		if m.watchers[cmd.ID] == fw {
+			delete(m.watchers, cmd.ID)
+		}
+		m.mu.Unlock()
+		cancel()
+	}()
+}
+
+type fileWatch struct {
+	cancel context.CancelFunc
+}
+
+func (m *Manager) stopWatcher(id uuid.UUID) {
+	m.mu.Lock()
+	fw, exists := m.watchers[id]
+	delete(m.watchers, id)
+	m.mu.Unlock()
+
+	if exists {
+		fw.cancel()
+	}
+}
+
+// Watching reports whether a command is re-executed on file changes.
+func (m *Manager) Watching(id uuid.UUID) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, exists := m.watchers[id]
+	return exists
+}
+
+func (m *Manager) onWatchChange(ctx context.Context, id uuid.UUID, mode command.WatchMode, paths []string) {
+	status, err := m.Status(id)
+	restart := err == nil && status.Active()
+	if restart {
+		if mode == command.WatchRerunIfIdle {
+			slog.Debug("ignoring change while command is running", "id", id, "paths", paths)
+			return
+		}
+		if err := m.stopInstance(id); err != nil {
+			slog.Warn("failed to stop command for restart", "id", id, "error", err)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	started, err := m.start(ctx, id, TriggerWatch, paths)
+	if err != nil {
+		if errors.Is(err, ErrCommandNotFound) {
+			m.stopWatcher(id)
+			return
+		}
+		slog.Warn("failed to re-execute command on change", "id", id, "error", err)
+		return
+	}
+	if started && restart {
+		m.publishRestart(id)
+	}
+}
@@ -0,0 +1,88 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager_WatchRerunsCommandOnChange(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "test-once",
+		Command: "echo run",
+		WorkDir: dir,
+		Watch: &command.Watch{
+			Include:  []string{"*.go"},
+			Debounce: command.Duration(50 * time.Millisecond),
+			Mode:     command.WatchRerunIfIdle,
+		},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	defer func() { _ = m.Stop(cmd.ID) }()
+
+	assert.Eventually(t, func() bool {
+		status, _ := m.Status(cmd.ID)
+		return status == StatusStopped
+	}, 2*time.Second, 20*time.Millisecond)
+	assert.True(t, m.Watching(cmd.ID))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main"), 0644))
+
+	assert.Eventually(t, func() bool {
+		runs, _ := m.Runs(cmd.ID)
+		return len(runs) == 2 && !runs[1].EndedAt.IsZero()
+	}, 2*time.Second, 20*time.Millisecond)
+
+	runs, err := m.Runs(cmd.ID)
+	require.NoError(t, err)
+	assert.Equal(t, TriggerManual, runs[0].Trigger)
+	assert.Equal(t, TriggerWatch, runs[1].Trigger)
+	assert.Equal(t, 2, runs[1].Number)
+	assert.Equal(t, []string{"main.go"}, runs[1].Changes)
+	require.NotNil(t, runs[1].ExitCode)
+	assert.Equal(t, 0, *runs[1].ExitCode)
+
+	require.NoError(t, m.Stop(cmd.ID))
+	assert.False(t, m.Watching(cmd.ID))
+}
+
+func TestManager_WatchRestartsRunningCommand(t *testing.T) {
+	dir := t.TempDir()
+	store := newTestStore()
+	cmd, err := store.Create(command.Command{
+		Name:    "server",
+		Command: "sleep 60",
+		WorkDir: dir,
+		Watch:   &command.Watch{Debounce: command.Duration(50 * time.Millisecond)},
+	})
+	require.NoError(t, err)
+
+	m := New(store)
+	_, err = m.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	defer func() { _ = m.Stop(cmd.ID) }()
+	time.Sleep(100 * time.Millisecond)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("a: 1"), 0644))
+
+	assert.Eventually(t, func() bool {
+		runs, _ := m.Runs(cmd.ID)
+		return len(runs) == 2
+	}, 2*time.Second, 20*time.Millisecond)
+
+	status, err := m.Status(cmd.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, status)
+}
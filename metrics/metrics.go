@@ -0,0 +1,211 @@
+// Package metrics renders metrics in the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets suit HTTP latencies in seconds.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Sample is one series of a family, with label values in the order the
+// family declared its label names.
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and renders them in registration order.
+type Registry struct {
+	mu       sync.Mutex
+	families []family
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(f family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.families = append(r.families, f)
+}
+
+// CounterFunc registers a counter whose samples are read from collect on
+// every scrape.
+func (r *Registry) CounterFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&funcFamily{name: name, help: help, kind: "counter", labels: labels, collect: collect})
+}
+
+// GaugeFunc registers a gauge whose samples are read from collect on every
+// scrape.
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&funcFamily{name: name, help: help, kind: "gauge", labels: labels, collect: collect})
+}
+
+// Histogram registers a histogram with the given upper bounds.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		name:    name,
+		help:    help,
+		labels:  labels,
+		buckets: slices.Sorted(slices.Values(buckets)),
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+// WriteText renders every family.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	families := slices.Clone(r.families)
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler serves the registry to scrapers.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", ContentType)
+		_ = r.WriteText(w)
+	})
+}
+
+type funcFamily struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	collect func() []Sample
+}
+
+func (f *funcFamily) write(w *bufio.Writer) {
+	samples := f.collect()
+	slices.SortFunc(samples, func(a, b Sample) int { return slices.Compare(a.Labels, b.Labels) })
+
+	writeHeader(w, f.name, f.help, f.kind)
+	for _, s := range samples {
+		writeSample(w, f.name, f.labels, s.Labels, "", "", s.Value)
+	}
+}
+
+// Histogram counts observations into cumulative buckets per label set.
+type Histogram struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Observe records v for the series with the given label values.
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{labels: slices.Clone(labelValues), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	series := make([]histogramSeries, 0, len(h.series))
+	for _, s := range h.series {
+		series = append(series, histogramSeries{labels: s.labels, counts: slices.Clone(s.counts), count: s.count, sum: s.sum})
+	}
+	h.mu.Unlock()
+	slices.SortFunc(series, func(a, b histogramSeries) int { return slices.Compare(a.labels, b.labels) })
+
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, s := range series {
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", formatFloat(upper), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labels, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labels, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labels, "", "", float64(s.count))
+	}
+}
+
+func writeHeader(w *bufio.Writer, name, help, kind string) {
+	w.WriteString("# HELP " + name + " " + helpEscaper.Replace(help) + "\n")
+	w.WriteString("# TYPE " + name + " " + kind + "\n")
+}
+
+func writeSample(w *bufio.Writer, name string, names, values []string, extraName, extraValue string, v float64) {
+	w.WriteString(name)
+	if len(names) > 0 || extraName != "" {
+		w.WriteByte('{')
+		sep := ""
+		for i, n := range names {
+			value := ""
+			if i < len(values) {
+				value = values[i]
+			}
+			w.WriteString(sep + n + `="` + labelEscaper.Replace(value) + `"`)
+			sep = ","
+		}
+		if extraName != "" {
+			w.WriteString(sep + extraName + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + formatFloat(v) + "\n")
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_WriteText(t *testing.T) {
+	reg := NewRegistry()
+	reg.GaugeFunc("jobs", "Jobs by state.", []string{"state"}, func() []Sample {
+		return []Sample{
+			{Labels: []string{"running"}, Value: 2},
+			{Labels: []string{"idle"}, Value: 0.5},
+		}
+	})
+	reg.CounterFunc("events_total", "Events\nseen.", nil, func() []Sample {
+		return []Sample{{Value: 7}}
+	})
+	h := reg.Histogram("latency_seconds", "Latency.", []float64{0.5, 0.1}, "route")
+	h.Observe(0.05, "/a")
+	h.Observe(0.1, "/a")
+	h.Observe(0.3, "/a")
+	h.Observe(2, "/a")
+	h.Observe(0.2, `/b"q`)
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.WriteText(&buf))
+
+	assert.Equal(t, `# HELP jobs Jobs by state.
+# TYPE jobs gauge
+jobs{state="idle"} 0.5
+jobs{state="running"} 2
+# HELP events_total Events\nseen.
+# TYPE events_total counter
+events_total 7
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 2
+latency_seconds_bucket{route="/a",le="0.5"} 3
+latency_seconds_bucket{route="/a",le="+Inf"} 4
+latency_seconds_sum{route="/a"} 2.45
+latency_seconds_count{route="/a"} 4
+latency_seconds_bucket{route="/b\"q",le="0.1"} 0
+latency_seconds_bucket{route="/b\"q",le="0.5"} 1
+latency_seconds_bucket{route="/b\"q",le="+Inf"} 1
+latency_seconds_sum{route="/b\"q"} 0.2
+latency_seconds_count{route="/b\"q"} 1
+`, buf.String())
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	reg := NewRegistry()
+	reg.GaugeFunc("up", "Up.", nil, func() []Sample { return []Sample{{Value: 1}} })
+
+	rec := httptest.NewRecorder()
+	reg.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "up 1\n")
+}
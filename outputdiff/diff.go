@@ -0,0 +1,187 @@
+package outputdiff
+
+import "slices"
+
+type Op string
+
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// Line is one line of a hunk. Deleted lines carry the text of the old
+// output, equal and inserted lines the text of the new one.
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Hunk is a contiguous group of changes with surrounding context. Line
+// numbers are 1-based.
+type Hunk struct {
+	FromStart int    `json:"from_start"`
+	FromLines int    `json:"from_lines"`
+	ToStart   int    `json:"to_start"`
+	ToLines   int    `json:"to_lines"`
+	Lines     []Line `json:"lines"`
+}
+
+type Result struct {
+	Added   int    `json:"added"`
+	Removed int    `json:"removed"`
+	Hunks   []Hunk `json:"hunks"`
+}
+
+type edit struct {
+	op   Op
+	from int // index into the old lines, valid for equal and delete
+	to   int // index into the new lines, valid for equal and insert
+}
+
+// Compute diffs two outputs line by line. Lines are compared after
+// normalization and reported with their original text; context is the
+// number of unchanged lines kept around each change.
+func Compute(from, to []string, norm *Normalizer, context int) Result {
+	a := make([]string, len(from))
+	for i, line := range from {
+		a[i] = norm.Line(line)
+	}
+	b := make([]string, len(to))
+	for i, line := range to {
+		b[i] = norm.Line(line)
+	}
+
+	edits := myers(a, b)
+	result := Result{Hunks: []Hunk{}}
+	for _, e := range edits {
+		switch e.op {
+		case OpInsert:
+			result.Added++
+		case OpDelete:
+			result.Removed++
+		}
+	}
+
+	for i := 0; i < len(edits); {
+		if edits[i].op == OpEqual {
+			i++
+			continue
+		}
+
+		start := max(0, i-context)
+		end := i
+		for end < len(edits) {
+			if edits[end].op != OpEqual {
+				end++
+				continue
+			}
+			j := end
+			for j < len(edits) && edits[j].op == OpEqual {
+				j++
+			}
+			if j == len(edits) || j-end > 2*context {
+				end = min(end+context, len(edits))
+				break
+			}
+			end = j
+		}
+
+		result.Hunks = append(result.Hunks, newHunk(edits[start:end], from, to))
+		i = end
+	}
+	return result
+}
+
+func newHunk(edits []edit, from, to []string) Hunk {
+	h := Hunk{Lines: make([]Line, 0, len(edits))}
+
+	// Position the hunk where it applies even when one side is empty.
+	first := edits[0]
+	h.FromStart, h.ToStart = first.from+1, first.to+1
+
+	for _, e := range edits {
+		switch e.op {
+		case OpEqual:
+			h.FromLines++
+			h.ToLines++
+			h.Lines = append(h.Lines, Line{Op: OpEqual, Text: to[e.to]})
+		case OpDelete:
+			h.FromLines++
+			h.Lines = append(h.Lines, Line{Op: OpDelete, Text: from[e.from]})
+		case OpInsert:
+			h.ToLines++
+			h.Lines = append(h.Lines, Line{Op: OpInsert, Text: to[e.to]})
+		}
+	}
+	return h
+}
+
+// myers returns the shortest edit script turning a into b, using the
+// O(ND) algorithm from Myers' "An O(ND) Difference Algorithm and Its
+// Variations". Only the diagonals reachable at each step are kept, so
+// memory grows with the square of the edit distance rather than the input.
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	maxD := n + m
+
+	v := make([]int, 2*maxD+3)
+	offset := maxD + 1
+	var trace [][]int
+
+search:
+	for d := 0; d <= maxD; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d-1:offset+d+2]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		snap := trace[d]
+		at := func(k int) int { return snap[k+d+1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{op: OpEqual, from: x, to: y})
+		}
+		if d > 0 {
+			if x == prevX {
+				edits = append(edits, edit{op: OpInsert, from: x, to: y - 1})
+			} else {
+				edits = append(edits, edit{op: OpDelete, from: x - 1, to: y})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	slices.Reverse(edits)
+	return edits
+}
@@ -0,0 +1,110 @@
+package outputdiff
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func plain(t *testing.T) *Normalizer {
+	t.Helper()
+	n, err := NewNormalizer(&Normalization{Disable: []Rule{RuleTimestamps, RuleDurations, RuleTempPaths, RuleHexAddresses}})
+	require.NoError(t, err)
+	return n
+}
+
+func TestCompute_Identical(t *testing.T) {
+	lines := []string{"a", "b", "c"}
+
+	r := Compute(lines, lines, plain(t), 3)
+
+	assert.Equal(t, Result{Hunks: []Hunk{}}, r)
+}
+
+func TestCompute_SingleChange(t *testing.T) {
+	from := strings.Split("1 2 3 4 5 6 7 8 9", " ")
+	to := strings.Split("1 2 3 4 x 6 7 8 9", " ")
+
+	r := Compute(from, to, plain(t), 2)
+
+	assert.Equal(t, 1, r.Added)
+	assert.Equal(t, 1, r.Removed)
+	require.Len(t, r.Hunks, 1)
+	h := r.Hunks[0]
+	assert.Equal(t, 3, h.FromStart)
+	assert.Equal(t, 5, h.FromLines)
+	assert.Equal(t, 3, h.ToStart)
+	assert.Equal(t, 5, h.ToLines)
+	assert.Equal(t, []Line{
+		{OpEqual, "3"}, {OpEqual, "4"}, {OpDelete, "5"}, {OpInsert, "x"}, {OpEqual, "6"}, {OpEqual, "7"},
+	}, h.Lines)
+}
+
+func TestCompute_SeparateHunks(t *testing.T) {
+	from := strings.Split("a b c d e f g h i j k l", " ")
+	to := strings.Split("A b c d e f g h i j k L", " ")
+
+	r := Compute(from, to, plain(t), 1)
+	require.Len(t, r.Hunks, 2)
+	assert.Equal(t, 1, r.Hunks[0].FromStart)
+	assert.Equal(t, 11, r.Hunks[1].FromStart)
+
+	r = Compute(from, to, plain(t), 5)
+	require.Len(t, r.Hunks, 1, "overlapping context merges hunks")
+}
+
+func TestCompute_EmptySides(t *testing.T) {
+	r := Compute(nil, []string{"new"}, plain(t), 3)
+	assert.Equal(t, 1, r.Added)
+	require.Len(t, r.Hunks, 1)
+	assert.Equal(t, []Line{{OpInsert, "new"}}, r.Hunks[0].Lines)
+
+	r = Compute([]string{"old"}, nil, plain(t), 3)
+	assert.Equal(t, 1, r.Removed)
+	assert.Equal(t, []Line{{OpDelete, "old"}}, r.Hunks[0].Lines)
+}
+
+func TestCompute_NormalizesBeforeComparing(t *testing.T) {
+	norm, err := NewNormalizer(nil)
+	require.NoError(t, err)
+
+	from := []string{"ok  \texample.com/calc\t0.012s", "panic at 0xc000012345"}
+	to := []string{"ok  \texample.com/calc\t0.034s", "panic at 0xc000099999"}
+
+	r := Compute(from, to, norm, 3)
+
+	assert.Empty(t, r.Hunks)
+}
+
+func TestMyers_EditScriptRebuildsBothSides(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	words := []string{"a", "b", "c", "d"}
+	gen := func() []string {
+		out := make([]string, rng.Intn(20))
+		for i := range out {
+			out[i] = words[rng.Intn(len(words))]
+		}
+		return out
+	}
+
+	for range 200 {
+		a, b := gen(), gen()
+		gotA, gotB := []string{}, []string{}
+		for _, e := range myers(a, b) {
+			switch e.op {
+			case OpEqual:
+				gotA = append(gotA, a[e.from])
+				gotB = append(gotB, b[e.to])
+			case OpDelete:
+				gotA = append(gotA, a[e.from])
+			case OpInsert:
+				gotB = append(gotB, b[e.to])
+			}
+		}
+		assert.Equal(t, a, gotA)
+		assert.Equal(t, b, gotB)
+	}
+}
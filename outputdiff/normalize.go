@@ -0,0 +1,102 @@
+package outputdiff
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+var ErrInvalidNormalization = errors.New("invalid normalization")
+
+// Rule names a built-in normalization. All built-in rules are applied unless
+// disabled.
+type Rule string
+
+const (
+	RuleTimestamps   Rule = "timestamps"
+	RuleDurations    Rule = "durations"
+	RuleTempPaths    Rule = "temp_paths"
+	RuleHexAddresses Rule = "hex_addresses"
+)
+
+type rewrite struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+type builtin struct {
+	name Rule
+	rewrite
+}
+
+// Order matters: timestamps are replaced before durations so that the
+// seconds of a clock time are not mistaken for a duration.
+var builtinRules = []builtin{
+	{RuleTimestamps, rewrite{regexp.MustCompile(`\d{4}[-/]\d{2}[-/]\d{2}(?:[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)?|\b\d{2}:\d{2}:\d{2}(?:[.,]\d+)?\b`), "<time>"}},
+	{RuleDurations, rewrite{regexp.MustCompile(`\b\d+(?:\.\d+)?(?:ns|µs|us|ms|s|m|h)\b|\(\d+(?:\.\d+)?s\)`), "<duration>"}},
+	{RuleTempPaths, rewrite{regexp.MustCompile(`(?:/private)?/(?:tmp|var/folders)/[^\s:'"]*`), "<tmp>"}},
+	{RuleHexAddresses, rewrite{regexp.MustCompile(`\b0x[0-9a-fA-F]+\b`), "<addr>"}},
+}
+
+// Replacement is a user-supplied rewrite applied after the built-in rules.
+// Replace may reference capture groups as in regexp.Expand.
+type Replacement struct {
+	Regexp  string `json:"regexp"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// Normalization configures how run output is normalized before diffing.
+type Normalization struct {
+	Disable []Rule        `json:"disable,omitempty"`
+	Replace []Replacement `json:"replace,omitempty"`
+}
+
+// Validate reports whether n names only known rules and compiles.
+func Validate(n *Normalization) error {
+	_, err := NewNormalizer(n)
+	return err
+}
+
+// Normalizer rewrites volatile fragments of output lines so that two runs
+// can be compared on what actually changed.
+type Normalizer struct {
+	rewrites []rewrite
+}
+
+// NewNormalizer builds a normalizer; a nil configuration applies every
+// built-in rule.
+func NewNormalizer(n *Normalization) (*Normalizer, error) {
+	if n == nil {
+		n = &Normalization{}
+	}
+
+	for _, rule := range n.Disable {
+		if !slices.ContainsFunc(builtinRules, func(b builtin) bool { return b.name == rule }) {
+			return nil, fmt.Errorf("%w: unknown rule %q", ErrInvalidNormalization, rule)
+		}
+	}
+
+	norm := &Normalizer{}
+	for _, b := range builtinRules {
+		if !slices.Contains(n.Disable, b.name) {
+			norm.rewrites = append(norm.rewrites, b.rewrite)
+		}
+	}
+	for _, r := range n.Replace {
+		re, err := regexp.Compile(r.Regexp)
+		if err != nil || r.Regexp == "" {
+			return nil, fmt.Errorf("%w: regexp %q", ErrInvalidNormalization, r.Regexp)
+		}
+		norm.rewrites = append(norm.rewrites, rewrite{re: re, replace: r.Replace})
+	}
+	return norm, nil
+}
+
+// Line returns the normalized form of line.
+func (n *Normalizer) Line(line string) string {
+	for _, r := range n.rewrites {
+		line = r.re.ReplaceAllString(line, r.replace)
+	}
+	return line
+}
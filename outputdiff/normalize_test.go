@@ -0,0 +1,42 @@
+package outputdiff
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizer_Builtins(t *testing.T) {
+	n, err := NewNormalizer(nil)
+	require.NoError(t, err)
+
+	for in, want := range map[string]string{
+		"2026-10-18T11:46:55.123Z request done":    "<time> request done",
+		"2026/10/18 11:46:55 WARN retrying":        "<time> WARN retrying",
+		"[11:46:55] compiled":                      "[<time>] compiled",
+		"--- PASS: TestAdd (0.02s)":                "--- PASS: TestAdd <duration>",
+		"took 150ms, then 2.5s":                    "took <duration>, then <duration>",
+		"open /tmp/go-build1234/b001/x.go: failed": "open <tmp>: failed",
+		"goroutine 1 [running]: 0xc00001a0f0":      "goroutine 1 [running]: <addr>",
+	} {
+		assert.Equal(t, want, n.Line(in), in)
+	}
+}
+
+func TestNormalizer_DisableAndReplace(t *testing.T) {
+	n, err := NewNormalizer(&Normalization{
+		Disable: []Rule{RuleHexAddresses},
+		Replace: []Replacement{{Regexp: `pid=\d+`, Replace: "pid=<pid>"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "worker pid=<pid> at 0xdead", n.Line("worker pid=4242 at 0xdead"))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate(nil))
+	assert.ErrorIs(t, Validate(&Normalization{Disable: []Rule{"colors"}}), ErrInvalidNormalization)
+	assert.ErrorIs(t, Validate(&Normalization{Replace: []Replacement{{Regexp: "("}}}), ErrInvalidNormalization)
+	assert.ErrorIs(t, Validate(&Normalization{Replace: []Replacement{{}}}), ErrInvalidNormalization)
+}
@@ -0,0 +1,296 @@
+// Package policy restricts which commands may be defined and run.
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrDenied        = errors.New("denied by policy")
+	ErrReadOnly      = errors.New("command definitions are read-only")
+	ErrInvalidPolicy = errors.New("invalid policy")
+)
+
+// Rules is the serialized form of a Policy. Empty lists impose no
+// restriction.
+type Rules struct {
+	// Executables lists the programs a command may run. A bare name only
+	// matches programs looked up on PATH; an entry containing a slash
+	// matches that exact path. Commands may then not set PATH or loader
+	// variables, and may only redirect output to /dev/null, another file
+	// descriptor or a file inside WorkDirRoots.
+	Executables []string `json:"executables,omitempty"`
+	// Patterns are regular expressions; a command line matching any of
+	// them is allowed regardless of Executables.
+	Patterns []string `json:"patterns,omitempty"`
+	// WorkDirRoots are directories a command's work_dir must be inside.
+	WorkDirRoots []string `json:"work_dir_roots,omitempty"`
+	// ReadOnly rejects every create, update and delete through the API.
+	ReadOnly bool `json:"read_only,omitempty"`
+}
+
+// Policy is a compiled set of Rules. The zero value allows everything.
+type Policy struct {
+	executables []string
+	patterns    []*regexp.Regexp
+	roots       []string
+	readOnly    bool
+}
+
+// New compiles rules. Relative work_dir roots are resolved against the
+// current directory.
+func New(rules Rules) (*Policy, error) {
+	p := &Policy{
+		executables: slices.Clone(rules.Executables),
+		readOnly:    rules.ReadOnly,
+	}
+	for _, e := range rules.Executables {
+		if strings.TrimSpace(e) == "" {
+			return nil, fmt.Errorf("%w: empty executable", ErrInvalidPolicy)
+		}
+	}
+	for _, expr := range rules.Patterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%w: pattern %q: %v", ErrInvalidPolicy, expr, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	for _, root := range rules.WorkDirRoots {
+		if root == "" {
+			return nil, fmt.Errorf("%w: empty work_dir root", ErrInvalidPolicy)
+		}
+		resolved, err := resolve(root)
+		if err != nil {
+			return nil, fmt.Errorf("%w: work_dir root %q: %v", ErrInvalidPolicy, root, err)
+		}
+		p.roots = append(p.roots, resolved)
+	}
+	return p, nil
+}
+
+// ReadOnly reports whether definitions may only come from configuration.
+func (p *Policy) ReadOnly() bool {
+	return p.readOnly
+}
+
+// AllowDefine reports whether cmd may be created or updated.
+func (p *Policy) AllowDefine(cmd command.Command) error {
+	if p.readOnly {
+		return ErrReadOnly
+	}
+	return p.check(cmd)
+}
+
+// AllowDelete reports whether cmd may be deleted.
+func (p *Policy) AllowDelete(command.Command) error {
+	if p.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// AllowRun reports whether cmd may be started. It applies to commands from
+// every source, so definitions that predate a policy change are caught too.
+func (p *Policy) AllowRun(cmd command.Command) error {
+	return p.check(cmd)
+}
+
+func (p *Policy) check(cmd command.Command) error {
+	if err := p.checkCommand(cmd.Command, cmd.WorkDir); err != nil {
+		return err
+	}
+	if err := p.checkEnv(cmd.Env); err != nil {
+		return err
+	}
+	return p.checkWorkDir(cmd.WorkDir)
+}
+
+func (p *Policy) restrictsCommands() bool {
+	return len(p.executables) > 0 || len(p.patterns) > 0
+}
+
+func (p *Policy) checkCommand(line, workDir string) error {
+	if !p.restrictsCommands() {
+		return nil
+	}
+	for _, re := range p.patterns {
+		if re.MatchString(line) {
+			return nil
+		}
+	}
+	if len(p.executables) == 0 {
+		return fmt.Errorf("%w: command does not match any allowed pattern", ErrDenied)
+	}
+
+	s, err := parse(line)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrDenied, err)
+	}
+	for _, prog := range s.programs {
+		if !p.allowedExecutable(prog) {
+			return fmt.Errorf("%w: executable %q is not allowed", ErrDenied, prog)
+		}
+	}
+	for _, name := range s.assigned {
+		if lookupVariable(name) {
+			return fmt.Errorf("%w: command sets %s", ErrDenied, name)
+		}
+	}
+	for _, r := range s.redirects {
+		if err := p.checkRedirect(r, workDir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupVariable reports whether a variable changes which code a program
+// name runs: the executable search path, dynamic loader settings, shell
+// startup files and exported shell functions.
+func lookupVariable(name string) bool {
+	switch name {
+	case "PATH", "ENV", "BASH_ENV":
+		return true
+	}
+	return strings.HasPrefix(name, "LD_") || strings.HasPrefix(name, "DYLD_") || strings.HasPrefix(name, "BASH_FUNC_")
+}
+
+// checkEnv rejects environment variables that would make allowed
+// executables resolve to other code.
+func (p *Policy) checkEnv(env []command.EnvVar) error {
+	if !p.restrictsCommands() {
+		return nil
+	}
+	for _, v := range env {
+		if lookupVariable(v.Name) {
+			return fmt.Errorf("%w: env sets %s", ErrDenied, v.Name)
+		}
+	}
+	return nil
+}
+
+// checkRedirect allows output redirections only to /dev/null, to another
+// file descriptor, or to a file inside the work_dir roots. Targets with
+// expansions cannot be resolved and are rejected.
+func (p *Policy) checkRedirect(r redirect, workDir string) error {
+	if !r.writes() || r.duplicate() || r.target == "/dev/null" {
+		return nil
+	}
+	if len(p.roots) > 0 && !strings.ContainsAny(r.target, "$~*?[") {
+		target := r.target
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(workDir, target)
+		}
+		if resolved, err := resolve(target); err == nil && p.insideRoots(resolved) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: output redirection to %q is not allowed", ErrDenied, r.target)
+}
+
+func (p *Policy) allowedExecutable(prog string) bool {
+	hasSlash := strings.Contains(prog, "/")
+	for _, e := range p.executables {
+		if strings.Contains(e, "/") {
+			if hasSlash && filepath.Clean(e) == filepath.Clean(prog) {
+				return true
+			}
+		} else if !hasSlash && e == prog {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) checkWorkDir(dir string) error {
+	if len(p.roots) == 0 {
+		return nil
+	}
+	resolved, err := resolve(dir)
+	if err != nil {
+		return fmt.Errorf("%w: work_dir %q: %v", ErrDenied, dir, err)
+	}
+	if !p.insideRoots(resolved) {
+		return fmt.Errorf("%w: work_dir %q is outside the allowed roots", ErrDenied, dir)
+	}
+	return nil
+}
+
+// insideRoots reports whether a resolved path is one of the roots or below
+// one.
+func (p *Policy) insideRoots(resolved string) bool {
+	for _, root := range p.roots {
+		if resolved == root || strings.HasPrefix(resolved, root+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the absolute, symlink-free form of path. Missing trailing
+// components are kept as written so directories that do not exist yet can
+// still be checked.
+func resolve(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		target, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{target}, rest...)...), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) || dir == filepath.Dir(dir) {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(dir)}, rest...)
+	}
+}
+
+// Config is the file passed with -config: a policy plus command
+// definitions to load at startup.
+type Config struct {
+	Policy   Rules             `json:"policy"`
+	Commands []command.Command `json:"commands"`
+}
+
+// LoadConfig reads a Config from path. Commands without an ID get one
+// derived from their name so it stays stable across restarts.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("%w: %s: %v", ErrInvalidPolicy, path, err)
+	}
+
+	seen := make(map[string]bool, len(cfg.Commands))
+	for i := range cfg.Commands {
+		cmd := &cfg.Commands[i]
+		if cmd.Name == "" || cmd.Command == "" || cmd.WorkDir == "" {
+			return Config{}, fmt.Errorf("%w: %s: command %d needs name, command and work_dir", ErrInvalidPolicy, path, i)
+		}
+		if seen[cmd.Name] {
+			return Config{}, fmt.Errorf("%w: %s: duplicate command name %q", ErrInvalidPolicy, path, cmd.Name)
+		}
+		seen[cmd.Name] = true
+		if cmd.ID == uuid.Nil {
+			cmd.ID = uuid.NewSHA1(uuid.NameSpaceURL, []byte("ai-sensors:"+cmd.Name))
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,241 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrograms(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"go test ./...", []string{"go"}},
+		{"cd web && npm run dev", []string{"npm"}},
+		{"FOO=1 BAR=2 make build | tee out.log", []string{"make", "tee"}},
+		{"go test ./... 2>&1 > /tmp/out; echo done", []string{"go"}},
+		{`go build > "out file.log" 2>>err.log && npm test`, []string{"go", "npm"}},
+		{`echo "a; rm -rf /" 'b | c'`, nil},
+		{"if true; then make; fi", []string{"make"}},
+		{"(cd api && ./run.sh) & wait", []string{"./run.sh", "wait"}},
+		{"echo ${HOME}/x", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := programs(tt.line)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPrograms_RejectsUncheckableConstructs(t *testing.T) {
+	for _, line := range []string{
+		"echo $(curl evil)",
+		"echo `id`",
+		`echo "$(id)"`,
+		"eval $CMD",
+		"diff <(ls a) <(ls b)",
+		"go build 2>`touch${IFS}/tmp/x`",
+		"go build >$(touch /tmp/x)",
+		`go build > "$(touch /tmp/x)"`,
+		"go build 2>&1 >out`id`",
+		"for f in *; do rm $f; done",
+		"echo 'unterminated",
+	} {
+		t.Run(line, func(t *testing.T) {
+			_, err := programs(line)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestPolicy_ZeroRulesAllowEverything(t *testing.T) {
+	p, err := New(Rules{})
+	require.NoError(t, err)
+
+	cmd := command.Command{Command: "rm -rf $(pwd)", WorkDir: "/"}
+	assert.NoError(t, p.AllowDefine(cmd))
+	assert.NoError(t, p.AllowRun(cmd))
+}
+
+func TestPolicy_Executables(t *testing.T) {
+	p, err := New(Rules{Executables: []string{"go", "npm", "/usr/local/bin/tool"}})
+	require.NoError(t, err)
+
+	assert.NoError(t, p.AllowRun(command.Command{Command: "go test ./... && npm test"}))
+	assert.NoError(t, p.AllowRun(command.Command{Command: "/usr/local/bin/tool --watch"}))
+
+	err = p.AllowRun(command.Command{Command: "go build && curl example.com"})
+	assert.ErrorIs(t, err, ErrDenied)
+	assert.Contains(t, err.Error(), `"curl"`)
+
+	// A bare name only matches a PATH lookup, not a binary elsewhere.
+	assert.ErrorIs(t, p.AllowRun(command.Command{Command: "/tmp/evil/go test"}), ErrDenied)
+	assert.ErrorIs(t, p.AllowRun(command.Command{Command: "go test $(curl x)"}), ErrDenied)
+}
+
+func TestPolicy_LookupVariables(t *testing.T) {
+	p, err := New(Rules{Executables: []string{"go"}})
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		"export PATH=/tmp/evil; go build",
+		"export LD_PRELOAD; go build",
+		"PATH=/tmp/evil go build",
+		"LD_LIBRARY_PATH=/tmp/evil go build",
+		"BASH_ENV=/tmp/evil.sh go build",
+	} {
+		assert.ErrorIs(t, p.AllowRun(command.Command{Command: line}), ErrDenied, line)
+	}
+	assert.NoError(t, p.AllowRun(command.Command{Command: "export GOFLAGS=-v; CGO_ENABLED=0 go build"}))
+
+	for _, name := range []string{"PATH", "LD_PRELOAD", "DYLD_INSERT_LIBRARIES"} {
+		cmd := command.Command{Command: "go build", Env: []command.EnvVar{{Name: name, Value: "/tmp/evil"}}}
+		assert.ErrorIs(t, p.AllowRun(cmd), ErrDenied, name)
+	}
+	assert.NoError(t, p.AllowRun(command.Command{Command: "go build", Env: []command.EnvVar{{Name: "GOOS", Value: "linux"}}}))
+
+	unrestricted, err := New(Rules{})
+	require.NoError(t, err)
+	assert.NoError(t, unrestricted.AllowRun(command.Command{Command: "PATH=/opt/bin go build", Env: []command.EnvVar{{Name: "PATH", Value: "/opt/bin"}}}))
+}
+
+func TestPolicy_Redirects(t *testing.T) {
+	root := t.TempDir()
+	p, err := New(Rules{Executables: []string{"go"}})
+	require.NoError(t, err)
+	rooted, err := New(Rules{Executables: []string{"go"}, WorkDirRoots: []string{root}})
+	require.NoError(t, err)
+
+	for _, line := range []string{
+		"go build > /dev/null 2>&1",
+		"go build 2>&- >&2",
+		"go build < input.txt",
+	} {
+		assert.NoError(t, p.AllowRun(command.Command{Command: line, WorkDir: root}), line)
+	}
+
+	for _, line := range []string{
+		"echo pwned >> ~/.bashrc",
+		"go build > build.log",
+		"go build >& out.log",
+		"go build 2>$HOME/.profile",
+	} {
+		assert.ErrorIs(t, p.AllowRun(command.Command{Command: line, WorkDir: root}), ErrDenied, line)
+	}
+
+	assert.NoError(t, rooted.AllowRun(command.Command{Command: "go build > build.log 2>> logs/err.log", WorkDir: root}))
+	for _, line := range []string{
+		"echo pwned >> ~/.bashrc",
+		"go build > ../escape.log",
+		"go build > /etc/profile.d/x.sh",
+		"go build > $HOME/x",
+	} {
+		assert.ErrorIs(t, rooted.AllowRun(command.Command{Command: line, WorkDir: root}), ErrDenied, line)
+	}
+}
+
+func TestPolicy_Patterns(t *testing.T) {
+	p, err := New(Rules{
+		Executables: []string{"go"},
+		Patterns:    []string{`^docker compose (up|logs -f)$`},
+	})
+	require.NoError(t, err)
+
+	assert.NoError(t, p.AllowRun(command.Command{Command: "docker compose up"}))
+	assert.NoError(t, p.AllowRun(command.Command{Command: "go vet ./..."}))
+	assert.ErrorIs(t, p.AllowRun(command.Command{Command: "docker compose down"}), ErrDenied)
+
+	patternsOnly, err := New(Rules{Patterns: []string{`^make \w+$`}})
+	require.NoError(t, err)
+	assert.ErrorIs(t, patternsOnly.AllowRun(command.Command{Command: "go test"}), ErrDenied)
+}
+
+func TestPolicy_WorkDirRoots(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(root, "api"), 0o755))
+	outside := t.TempDir()
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "escape")))
+
+	p, err := New(Rules{WorkDirRoots: []string{root}})
+	require.NoError(t, err)
+
+	assert.NoError(t, p.AllowRun(command.Command{Command: "ls", WorkDir: root}))
+	assert.NoError(t, p.AllowRun(command.Command{Command: "ls", WorkDir: filepath.Join(root, "api")}))
+	assert.NoError(t, p.AllowRun(command.Command{Command: "ls", WorkDir: filepath.Join(root, "not-yet")}))
+
+	for _, dir := range []string{
+		outside,
+		filepath.Join(root, ".."),
+		root + "-sibling",
+		filepath.Join(root, "escape"),
+		filepath.Join(root, "escape", "deeper"),
+	} {
+		assert.ErrorIs(t, p.AllowRun(command.Command{Command: "ls", WorkDir: dir}), ErrDenied, dir)
+	}
+}
+
+func TestPolicy_ReadOnly(t *testing.T) {
+	p, err := New(Rules{ReadOnly: true})
+	require.NoError(t, err)
+
+	cmd := command.Command{Command: "go test", WorkDir: "/tmp"}
+	assert.ErrorIs(t, p.AllowDefine(cmd), ErrReadOnly)
+	assert.ErrorIs(t, p.AllowDelete(cmd), ErrReadOnly)
+	assert.NoError(t, p.AllowRun(cmd))
+}
+
+func TestNew_InvalidRules(t *testing.T) {
+	_, err := New(Rules{Patterns: []string{"("}})
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+
+	_, err = New(Rules{Executables: []string{" "}})
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+
+	_, err = New(Rules{WorkDirRoots: []string{""}})
+	assert.ErrorIs(t, err, ErrInvalidPolicy)
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"policy": {"executables": ["go"], "read_only": true},
+		"commands": [
+			{"name": "test", "command": "go test ./...", "work_dir": "/tmp"},
+			{"id": "0195c2a0-0000-7000-8000-000000000001", "name": "vet", "command": "go vet ./...", "work_dir": "/tmp"}
+		]
+	}`), 0o600))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.True(t, cfg.Policy.ReadOnly)
+	assert.Equal(t, []string{"go"}, cfg.Policy.Executables)
+	require.Len(t, cfg.Commands, 2)
+	assert.NotEqual(t, uuid.Nil, cfg.Commands[0].ID)
+	assert.Equal(t, "0195c2a0-0000-7000-8000-000000000001", cfg.Commands[1].ID.String())
+
+	again, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Commands[0].ID, again.Commands[0].ID, "derived IDs are stable")
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"syntax":    `{"commands": [`,
+		"missing":   `{"commands": [{"name": "x"}]}`,
+		"duplicate": `{"commands": [{"name": "x", "command": "a", "work_dir": "/"}, {"name": "x", "command": "b", "work_dir": "/"}]}`,
+	} {
+		path := filepath.Join(dir, name+".json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		_, err := LoadConfig(path)
+		assert.ErrorIs(t, err, ErrInvalidPolicy, name)
+	}
+}
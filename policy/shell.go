@@ -0,0 +1,222 @@
+package policy
+
+import (
+	"errors"
+	"strings"
+)
+
+var errUnparsable = errors.New("command uses shell constructs the policy cannot check")
+
+// harmlessBuiltins may appear in any command; they cannot run other
+// programs.
+var harmlessBuiltins = map[string]bool{
+	"cd": true, "echo": true, "printf": true, "true": true, "false": true,
+	"exit": true, "export": true, "set": true, "test": true, "[": true, ":": true,
+}
+
+// prefixKeywords introduce a command without being one.
+var prefixKeywords = map[string]bool{
+	"if": true, "then": true, "else": true, "elif": true,
+	"while": true, "until": true, "do": true, "!": true,
+}
+
+// closingKeywords end a compound command and run nothing.
+var closingKeywords = map[string]bool{"fi": true, "done": true}
+
+// script is what a command line does that the policy checks.
+type script struct {
+	// programs are the programs it runs, in order.
+	programs []string
+	// assigned are the variables it assigns or exports.
+	assigned []string
+	// redirects are its redirections, in order.
+	redirects []redirect
+}
+
+// redirect is one redirection: the operator without any file descriptor
+// number, such as ">", ">>" or ">&", and the target word.
+type redirect struct {
+	op     string
+	target string
+}
+
+// writes reports whether the redirection writes to its target.
+func (r redirect) writes() bool {
+	return strings.Contains(r.op, ">")
+}
+
+// duplicate reports whether the redirection copies a file descriptor, as in
+// 2>&1, rather than opening a file.
+func (r redirect) duplicate() bool {
+	if !strings.HasSuffix(r.op, "&") {
+		return false
+	}
+	return r.target == "-" || strings.Trim(r.target, "0123456789") == ""
+}
+
+// programs returns the programs a shell command line runs, in order.
+func programs(line string) ([]string, error) {
+	s, err := parse(line)
+	return s.programs, err
+}
+
+// parse analyses a shell command line. It is deliberately conservative:
+// command substitution, eval-like builtins and constructs it does not model
+// make it fail rather than guess.
+func parse(line string) (script, error) {
+	segments, redirects, err := splitCommands(line)
+	if err != nil {
+		return script{}, err
+	}
+
+	s := script{redirects: redirects}
+	for _, words := range segments {
+		for len(words) > 0 {
+			w := words[0]
+			switch {
+			case prefixKeywords[w]:
+				words = words[1:]
+				continue
+			case strings.Contains(w, "=") && !strings.HasPrefix(w, "="):
+				// A variable assignment before the program name.
+				name, _, _ := strings.Cut(w, "=")
+				s.assigned = append(s.assigned, name)
+				words = words[1:]
+				continue
+			}
+			break
+		}
+		if len(words) == 0 || closingKeywords[words[0]] {
+			continue
+		}
+
+		switch prog := words[0]; prog {
+		case "eval", "exec", "source", ".", "command", "builtin", "for", "case", "function":
+			return script{}, errUnparsable
+		case "export", "readonly", "declare", "typeset", "local":
+			for _, w := range words[1:] {
+				if !strings.HasPrefix(w, "-") {
+					name, _, _ := strings.Cut(w, "=")
+					s.assigned = append(s.assigned, name)
+				}
+			}
+			if prog != "export" {
+				s.programs = append(s.programs, prog)
+			}
+		default:
+			if !harmlessBuiltins[prog] {
+				s.programs = append(s.programs, prog)
+			}
+		}
+	}
+	return s, nil
+}
+
+// splitCommands splits a command line into simple commands at ; & | && ||
+// and newlines, each split into words with quotes removed, and collects the
+// redirections, whose targets are not words of any command. Grouping with
+// parentheses or braces is flattened.
+func splitCommands(line string) ([][]string, []redirect, error) {
+	var (
+		segments  [][]string
+		redirects []redirect
+		words     []string
+		word      strings.Builder
+		inWord    bool
+		// op is the operator of a redirection whose target is the next
+		// word: a file, not a program, so it is parsed like any word.
+		op string
+	)
+	endWord := func() {
+		if inWord {
+			if op != "" {
+				redirects = append(redirects, redirect{op: op, target: word.String()})
+			} else {
+				words = append(words, word.String())
+			}
+			word.Reset()
+			inWord = false
+			op = ""
+		}
+	}
+	endSegment := func() {
+		endWord()
+		op = ""
+		if len(words) > 0 {
+			segments = append(segments, words)
+			words = nil
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch c {
+		case '\'':
+			j := strings.IndexByte(line[i+1:], '\'')
+			if j < 0 {
+				return nil, nil, errUnparsable
+			}
+			word.WriteString(line[i+1 : i+1+j])
+			inWord = true
+			i += j + 1
+		case '"':
+			j := i + 1
+			for ; j < len(line) && line[j] != '"'; j++ {
+				if line[j] == '\\' {
+					j++
+				} else if line[j] == '`' || (line[j] == '$' && j+1 < len(line) && line[j+1] == '(') {
+					return nil, nil, errUnparsable
+				}
+			}
+			if j >= len(line) {
+				return nil, nil, errUnparsable
+			}
+			word.WriteString(line[i+1 : j])
+			inWord = true
+			i = j
+		case '\\':
+			if i+1 < len(line) {
+				i++
+				word.WriteByte(line[i])
+				inWord = true
+			}
+		case '`':
+			return nil, nil, errUnparsable
+		case '$':
+			if i+1 < len(line) && line[i+1] == '(' {
+				return nil, nil, errUnparsable
+			}
+			if i+1 < len(line) && line[i+1] == '{' {
+				j := strings.IndexByte(line[i:], '}')
+				if j < 0 || strings.ContainsAny(line[i+2:i+j], "`$") {
+					return nil, nil, errUnparsable
+				}
+				word.WriteString(line[i : i+j+1])
+				inWord = true
+				i += j
+				continue
+			}
+			word.WriteByte(c)
+			inWord = true
+		case '<', '>':
+			if i+1 < len(line) && line[i+1] == '(' {
+				return nil, nil, errUnparsable
+			}
+			endWord()
+			start := i
+			for i+1 < len(line) && (line[i+1] == '>' || line[i+1] == '&' || line[i+1] == '<') {
+				i++
+			}
+			op = line[start : i+1]
+		case ';', '&', '|', '\n', '(', ')', '{', '}':
+			endSegment()
+		case ' ', '\t', '\r':
+			endWord()
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	endSegment()
+	return segments, redirects, nil
+}
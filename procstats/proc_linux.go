@@ -0,0 +1,108 @@
+package procstats
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	procRoot  = "/proc"
+	supported = true
+)
+
+// clockTicks is USER_HZ, which is 100 on every mainstream Linux build.
+const clockTicks = 100
+
+// readGroup returns the processes in process group pgid plus any
+// descendants that have moved to a group of their own.
+func readGroup(root string, pgid int) ([]procInfo, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	all := make(map[int]procInfo)
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+		p, err := readProc(root, pid)
+		if err != nil {
+			continue // exited while we were looking
+		}
+		all[pid] = p
+	}
+
+	selected := make(map[int]bool)
+	for pid, p := range all {
+		if p.pgrp == pgid {
+			selected[pid] = true
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for pid, p := range all {
+			if !selected[pid] && selected[p.ppid] {
+				selected[pid] = true
+				changed = true
+			}
+		}
+	}
+
+	pids := make([]int, 0, len(selected))
+	for pid := range selected {
+		pids = append(pids, pid)
+	}
+	slices.Sort(pids)
+
+	procs := make([]procInfo, len(pids))
+	for i, pid := range pids {
+		procs[i] = all[pid]
+		procs[i].fds = countFDs(root, pid)
+	}
+	return procs, nil
+}
+
+// readProc parses /proc/<pid>/stat; see proc(5) for the field numbers.
+func readProc(root string, pid int) (procInfo, error) {
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return procInfo{}, err
+	}
+	stat := string(data)
+	open, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if open < 0 || end < open {
+		return procInfo{}, os.ErrInvalid
+	}
+	// fields[0] is field 3 (state).
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return procInfo{}, os.ErrInvalid
+	}
+	field := func(n int) int64 {
+		v, _ := strconv.ParseInt(fields[n-3], 10, 64)
+		return v
+	}
+
+	return procInfo{
+		key:     procKey{pid: pid, start: uint64(field(22))},
+		ppid:    int(field(4)),
+		pgrp:    int(field(5)),
+		name:    stat[open+1 : end],
+		ticks:   uint64(field(14) + field(15)),
+		threads: int(field(20)),
+		rss:     field(24) * int64(os.Getpagesize()),
+	}, nil
+}
+
+func countFDs(root string, pid int) int {
+	entries, err := os.ReadDir(filepath.Join(root, strconv.Itoa(pid), "fd"))
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
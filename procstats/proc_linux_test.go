@@ -0,0 +1,65 @@
+package procstats
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadProc_ParsesCommWithSpacesAndParens(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "42")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "fd"), 0o755))
+	stat := "42 (my (weird) app) S 1 42 42 0 -1 4194560 100 0 0 0 30 12 0 0 20 0 3 0 5000 1000000 256 18446744073709551615"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644))
+
+	p, err := readProc(root, 42)
+	require.NoError(t, err)
+
+	assert.Equal(t, "my (weird) app", p.name)
+	assert.Equal(t, 1, p.ppid)
+	assert.Equal(t, 42, p.pgrp)
+	assert.Equal(t, uint64(42), p.ticks)
+	assert.Equal(t, 3, p.threads)
+	assert.Equal(t, uint64(5000), p.key.start)
+	assert.Equal(t, int64(256*os.Getpagesize()), p.rss)
+}
+
+func TestSampler_ProcessGroup(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 30 & sleep 30 & wait")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		_ = cmd.Wait()
+	})
+
+	s := NewSampler(func() int { return cmd.Process.Pid }, 20*time.Millisecond, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Run(ctx)
+
+	require.Eventually(t, func() bool {
+		latest := s.Latest()
+		return latest != nil && latest.Processes == 3
+	}, 5*time.Second, 20*time.Millisecond)
+
+	latest := s.Latest()
+	require.Len(t, latest.Tree, 1)
+	assert.Equal(t, cmd.Process.Pid, latest.Tree[0].PID)
+	assert.Len(t, latest.Tree[0].Children, 2)
+	assert.Positive(t, latest.RSSBytes)
+	assert.Positive(t, latest.FDs)
+	assert.GreaterOrEqual(t, latest.Threads, 3)
+
+	require.Eventually(t, func() bool { return len(s.Samples(time.Time{})) >= 3 }, 5*time.Second, 20*time.Millisecond)
+	assert.LessOrEqual(t, len(s.Samples(time.Time{})), 10)
+	assert.Empty(t, s.Samples(time.Now().Add(time.Hour)))
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package procstats
+
+const (
+	procRoot   = ""
+	supported  = false
+	clockTicks = 100
+)
+
+func readGroup(string, int) ([]procInfo, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,198 @@
+// Package procstats samples the resource usage of a process group.
+package procstats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrUnsupported = errors.New("process metrics are only available on Linux")
+
+const (
+	DefaultInterval = time.Second
+	// DefaultCapacity keeps ten minutes of samples at the default interval.
+	DefaultCapacity = 600
+)
+
+// Supported reports whether sampling works on this platform.
+func Supported() bool {
+	return supported
+}
+
+// Sample is the usage of a whole process tree at one point in time. CPU is
+// a percentage of one core, as in top.
+type Sample struct {
+	Time       time.Time `json:"time"`
+	CPUPercent float64   `json:"cpu_percent"`
+	RSSBytes   int64     `json:"rss_bytes"`
+	Threads    int       `json:"threads"`
+	FDs        int       `json:"fds"`
+	Processes  int       `json:"processes"`
+}
+
+// Process is one member of the sampled tree.
+type Process struct {
+	PID        int        `json:"pid"`
+	PPID       int        `json:"ppid"`
+	Name       string     `json:"name"`
+	CPUPercent float64    `json:"cpu_percent"`
+	RSSBytes   int64      `json:"rss_bytes"`
+	Threads    int        `json:"threads"`
+	FDs        int        `json:"fds"`
+	Children   []*Process `json:"children,omitempty"`
+}
+
+// Snapshot is the latest sample with its per-process breakdown.
+type Snapshot struct {
+	Sample
+	Tree []*Process `json:"tree"`
+}
+
+// Sampler periodically records the usage of the process group led by the
+// PID that pid returns. A zero PID means the process has not started yet
+// and the tick is skipped.
+type Sampler struct {
+	pid      func() int
+	interval time.Duration
+	capacity int
+
+	mu      sync.RWMutex
+	samples []Sample
+	latest  *Snapshot
+	prev    map[procKey]uint64
+	prevAt  time.Time
+}
+
+func NewSampler(pid func() int, interval time.Duration, capacity int) *Sampler {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Sampler{pid: pid, interval: interval, capacity: capacity}
+}
+
+// Run samples until ctx is done. Samples stay readable afterwards.
+func (s *Sampler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.sample()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sample()
+		}
+	}
+}
+
+func (s *Sampler) sample() {
+	pid := s.pid()
+	if pid <= 0 {
+		return
+	}
+	procs, err := readGroup(procRoot, pid)
+	if err != nil || len(procs) == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	snap := buildSnapshot(now, procs, s.prev, now.Sub(s.prevAt))
+	s.prev = make(map[procKey]uint64, len(procs))
+	for _, p := range procs {
+		s.prev[p.key] = p.ticks
+	}
+	s.prevAt = now
+
+	s.latest = snap
+	s.samples = append(s.samples, snap.Sample)
+	if len(s.samples) > s.capacity {
+		s.samples = s.samples[len(s.samples)-s.capacity:]
+	}
+}
+
+// Samples returns the recorded samples taken after since, oldest first.
+func (s *Sampler) Samples(since time.Time) []Sample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	out := make([]Sample, 0, len(s.samples))
+	for _, sm := range s.samples {
+		if sm.Time.After(since) {
+			out = append(out, sm)
+		}
+	}
+	return out
+}
+
+// Latest returns the most recent snapshot, or nil before the first sample.
+func (s *Sampler) Latest() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.latest
+}
+
+// procKey tells a process apart from a later one reusing its PID.
+type procKey struct {
+	pid   int
+	start uint64
+}
+
+type procInfo struct {
+	key     procKey
+	ppid    int
+	pgrp    int
+	name    string
+	ticks   uint64
+	rss     int64
+	threads int
+	fds     int
+}
+
+// buildSnapshot aggregates procs into a tree. CPU is derived from the tick
+// delta against prev over elapsed; processes new since prev count all
+// their ticks.
+func buildSnapshot(now time.Time, procs []procInfo, prev map[procKey]uint64, elapsed time.Duration) *Snapshot {
+	snap := &Snapshot{Sample: Sample{Time: now, Processes: len(procs)}}
+	nodes := make(map[int]*Process, len(procs))
+	for _, p := range procs {
+		node := &Process{
+			PID:      p.key.pid,
+			PPID:     p.ppid,
+			Name:     p.name,
+			RSSBytes: p.rss,
+			Threads:  p.threads,
+			FDs:      p.fds,
+		}
+		if prev != nil && elapsed > 0 {
+			delta := p.ticks
+			if before, ok := prev[p.key]; ok {
+				delta = p.ticks - min(before, p.ticks)
+			}
+			node.CPUPercent = float64(delta) / clockTicks / elapsed.Seconds() * 100
+		}
+		nodes[node.PID] = node
+
+		snap.CPUPercent += node.CPUPercent
+		snap.RSSBytes += node.RSSBytes
+		snap.Threads += node.Threads
+		snap.FDs += node.FDs
+	}
+	for _, p := range procs {
+		node := nodes[p.key.pid]
+		if parent, ok := nodes[p.ppid]; ok {
+			parent.Children = append(parent.Children, node)
+		} else {
+			snap.Tree = append(snap.Tree, node)
+		}
+	}
+	return snap
+}
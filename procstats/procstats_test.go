@@ -0,0 +1,66 @@
+package procstats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSnapshot_TreeAndTotals(t *testing.T) {
+	procs := []procInfo{
+		{key: procKey{pid: 10, start: 1}, ppid: 1, name: "sh", ticks: 10, rss: 1 << 20, threads: 1, fds: 3},
+		{key: procKey{pid: 11, start: 2}, ppid: 10, name: "node", ticks: 250, rss: 100 << 20, threads: 8, fds: 40},
+		{key: procKey{pid: 12, start: 3}, ppid: 11, name: "esbuild", ticks: 50, rss: 20 << 20, threads: 4, fds: 10},
+	}
+	prev := map[procKey]uint64{{pid: 10, start: 1}: 10, {pid: 11, start: 2}: 150}
+
+	snap := buildSnapshot(time.Now(), procs, prev, 2*time.Second)
+
+	assert.Equal(t, 3, snap.Processes)
+	assert.Equal(t, int64(121<<20), snap.RSSBytes)
+	assert.Equal(t, 13, snap.Threads)
+	assert.Equal(t, 53, snap.FDs)
+	// node: 100 ticks over 2s is half a core; esbuild is new, 50 ticks.
+	assert.InDelta(t, 75.0, snap.CPUPercent, 0.001)
+
+	require.Len(t, snap.Tree, 1)
+	root := snap.Tree[0]
+	assert.Equal(t, "sh", root.Name)
+	assert.Zero(t, root.CPUPercent)
+	require.Len(t, root.Children, 1)
+	assert.Equal(t, "node", root.Children[0].Name)
+	assert.InDelta(t, 50.0, root.Children[0].CPUPercent, 0.001)
+	require.Len(t, root.Children[0].Children, 1)
+	assert.Equal(t, 12, root.Children[0].Children[0].PID)
+}
+
+func TestBuildSnapshot_FirstSampleHasNoCPU(t *testing.T) {
+	procs := []procInfo{{key: procKey{pid: 10}, ppid: 1, ticks: 500}}
+
+	snap := buildSnapshot(time.Now(), procs, nil, 0)
+
+	assert.Zero(t, snap.CPUPercent)
+}
+
+func TestBuildSnapshot_ReusedPIDIsANewProcess(t *testing.T) {
+	procs := []procInfo{{key: procKey{pid: 10, start: 99}, ppid: 1, ticks: 20}}
+	prev := map[procKey]uint64{{pid: 10, start: 1}: 400}
+
+	snap := buildSnapshot(time.Now(), procs, prev, time.Second)
+
+	assert.InDelta(t, 20.0, snap.CPUPercent, 0.001)
+}
+
+func TestSampler_SkipsUntilStarted(t *testing.T) {
+	s := NewSampler(func() int { return 0 }, 10*time.Millisecond, 5)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	s.Run(ctx)
+
+	assert.Nil(t, s.Latest())
+	assert.Empty(t, s.Samples(time.Time{}))
+}
@@ -0,0 +1,128 @@
+// Package redact removes secrets from command output before it is stored.
+package redact
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var ErrInvalidRedaction = errors.New("invalid redaction")
+
+// Placeholder replaces every redacted value.
+const Placeholder = "[REDACTED]"
+
+// minSecretLen is the shortest literal secret that is redacted. Shorter
+// values would match all over ordinary output.
+const minSecretLen = 4
+
+// Rule names a built-in detector. All built-in detectors are applied unless
+// disabled.
+type Rule string
+
+const (
+	RuleAWSKeys      Rule = "aws_keys"
+	RuleBearerTokens Rule = "bearer_tokens"
+	RulePrivateKeys  Rule = "private_keys"
+	RuleSecretEnv    Rule = "secret_env"
+)
+
+var knownRules = []Rule{RuleAWSKeys, RuleBearerTokens, RulePrivateKeys, RuleSecretEnv}
+
+// pattern replaces the match of re with replace, which may keep a leading
+// group so that, for example, a header name stays readable.
+type pattern struct {
+	re      *regexp.Regexp
+	replace string
+}
+
+var builtinPatterns = map[Rule][]pattern{
+	RuleAWSKeys: {
+		{regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b`), Placeholder},
+		{regexp.MustCompile(`(?i)(aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?)[A-Za-z0-9/+=]{40}`), "${1}" + Placeholder},
+	},
+	RuleBearerTokens: {
+		{regexp.MustCompile(`(?i)(\bbearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + Placeholder},
+		{regexp.MustCompile(`(?i)(\bauthorization["']?\s*[:=]\s*["']?(?:basic|token)\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}" + Placeholder},
+	},
+}
+
+var (
+	privateKeyBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+	privateKeyEnd   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY( BLOCK)?-----`)
+)
+
+// Config selects detectors for a command. Patterns are extra regular
+// expressions whose matches are redacted.
+type Config struct {
+	Disable  []Rule   `json:"disable,omitempty"`
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// Validate reports whether c names only known rules and its patterns compile.
+func Validate(c *Config) error {
+	_, err := New(c, nil)
+	return err
+}
+
+// Redactor rewrites lines so that no detected secret remains.
+type Redactor struct {
+	patterns    []pattern
+	secrets     []string
+	privateKeys bool
+}
+
+// New builds a redactor. secrets are literal values to remove, typically
+// the values of environment variables marked secret; a nil configuration
+// enables every built-in detector.
+func New(c *Config, secrets []string) (*Redactor, error) {
+	if c == nil {
+		c = &Config{}
+	}
+	for _, rule := range c.Disable {
+		if !slices.Contains(knownRules, rule) {
+			return nil, fmt.Errorf("%w: unknown rule %q", ErrInvalidRedaction, rule)
+		}
+	}
+	enabled := func(rule Rule) bool { return !slices.Contains(c.Disable, rule) }
+
+	r := &Redactor{privateKeys: enabled(RulePrivateKeys)}
+	for _, rule := range []Rule{RuleAWSKeys, RuleBearerTokens} {
+		if enabled(rule) {
+			r.patterns = append(r.patterns, builtinPatterns[rule]...)
+		}
+	}
+	for _, p := range c.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil || p == "" {
+			return nil, fmt.Errorf("%w: pattern %q", ErrInvalidRedaction, p)
+		}
+		r.patterns = append(r.patterns, pattern{re: re, replace: Placeholder})
+	}
+
+	if enabled(RuleSecretEnv) {
+		for _, s := range secrets {
+			if len(s) >= minSecretLen && !slices.Contains(r.secrets, s) {
+				r.secrets = append(r.secrets, s)
+			}
+		}
+		// Longer secrets first, so one that contains another is removed whole.
+		slices.SortFunc(r.secrets, func(a, b string) int { return cmp.Compare(len(b), len(a)) })
+	}
+	return r, nil
+}
+
+// Line redacts a single line. Private key blocks span lines and are handled
+// by Writer.
+func (r *Redactor) Line(line string) string {
+	for _, s := range r.secrets {
+		line = strings.ReplaceAll(line, s, Placeholder)
+	}
+	for _, p := range r.patterns {
+		line = p.re.ReplaceAllString(line, p.replace)
+	}
+	return line
+}
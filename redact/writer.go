@@ -0,0 +1,104 @@
+package redact
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+const (
+	// maxPending bounds how much of an unterminated line is held back
+	// waiting for its newline before it is redacted and passed on anyway.
+	maxPending = 64 * 1024
+
+	// maxKeyLines bounds how many lines are swallowed after a private key
+	// header, in case its footer never comes.
+	maxKeyLines = 200
+)
+
+// Writer redacts output line by line before passing it to the wrapped
+// writer. Lines are held until their newline arrives so that a secret split
+// across writes is still detected; Flush releases an unterminated last line.
+type Writer struct {
+	mu      sync.Mutex
+	r       *Redactor
+	dst     io.Writer
+	pending []byte
+	keyLine int // lines seen inside a private key block, 0 outside one
+}
+
+func (r *Redactor) Writer(dst io.Writer) *Writer {
+	return &Writer{r: r, dst: dst}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.pending = append(w.pending, p...)
+
+	var out []byte
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		if line, keep := w.redact(string(w.pending[:i])); keep {
+			out = append(out, line...)
+			out = append(out, '\n')
+		}
+		w.pending = w.pending[i+1:]
+	}
+	if len(w.pending) > maxPending {
+		if line, keep := w.redact(string(w.pending)); keep {
+			out = append(out, line...)
+		}
+		w.pending = nil
+	}
+	w.pending = bytes.Clone(w.pending)
+
+	if len(out) > 0 {
+		if _, err := w.dst.Write(out); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush passes on a held unterminated line, redacted.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.pending) == 0 {
+		return nil
+	}
+	line, keep := w.redact(string(w.pending))
+	w.pending = nil
+	if !keep {
+		return nil
+	}
+	_, err := w.dst.Write([]byte(line))
+	return err
+}
+
+// redact returns the redacted line and whether to emit it. A private key
+// block is replaced by a single placeholder line.
+func (w *Writer) redact(line string) (string, bool) {
+	if w.r.privateKeys {
+		if w.keyLine > 0 {
+			w.keyLine++
+			if privateKeyEnd.MatchString(line) || w.keyLine > maxKeyLines {
+				w.keyLine = 0
+			}
+			return "", false
+		}
+		if loc := privateKeyBegin.FindStringIndex(line); loc != nil {
+			if !privateKeyEnd.MatchString(line[loc[1]:]) {
+				w.keyLine = 1
+			}
+			return w.r.Line(line[:loc[0]]) + Placeholder + " private key", true
+		}
+	}
+	return w.r.Line(line), true
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLimitExceeded = errors.New("resource limit exceeded")
+	// ErrLimitsUnenforced reports that memory, CPU or process limits could
+	// not be placed in a cgroup; only the per-process rlimits apply.
+	ErrLimitsUnenforced = errors.New("resource limits not enforced")
+)
+
+// Limits bounds the resources of a process and its descendants. Zero fields
+// are unlimited.
+//
+// Memory, CPU and Processes are enforced by a cgroup v2 sub-tree when one
+// can be created, which needs Linux. Without cgroups, Memory falls back to
+// a per-process RLIMIT_DATA and CPU and Processes are not enforced;
+// Runner.LimitsError then says why. OpenFiles is always a per-process
+// RLIMIT_NOFILE. Timeout is wall-clock and works everywhere.
+type Limits struct {
+	// Memory is the maximum resident memory in bytes.
+	Memory int64
+	// CPU is the maximum CPU bandwidth in cores, e.g. 0.5 or 2.
+	CPU float64
+	// Processes is the maximum number of live processes and threads.
+	Processes int
+	// OpenFiles is the maximum number of open file descriptors per process.
+	OpenFiles uint64
+	// Timeout stops the process after it has run this long.
+	Timeout time.Duration
+}
+
+func (l Limits) IsZero() bool {
+	return l == Limits{}
+}
+
+func (l Limits) needsCgroup() bool {
+	return l.Memory > 0 || l.CPU > 0 || l.Processes > 0
+}
+
+// Termination explains why a process ended because of a limit.
+type Termination string
+
+const (
+	TerminationTimeout   Termination = "timeout"
+	TerminationMemory    Termination = "memory_limit"
+	TerminationProcesses Termination = "process_limit"
+)
+
+// withRlimits wraps name and args in a shell that sets the per-process
+// limits and then execs the command, so the limits are in place before it
+// can fork. The exec keeps the PID, and with it the process group. It
+// returns name and args unchanged when there is nothing to set.
+func withRlimits(limits Limits, cgroup bool, name string, args []string) (string, []string) {
+	var script []string
+	if limits.OpenFiles > 0 {
+		script = append(script, fmt.Sprintf("ulimit -n %d", limits.OpenFiles))
+	}
+	if limits.Memory > 0 && !cgroup {
+		script = append(script, fmt.Sprintf("ulimit -d %d", max(limits.Memory>>10, 1)))
+	}
+	if len(script) == 0 {
+		return name, args
+	}
+	// A failing ulimit reports to the command's output; the command still runs.
+	script = append(script, `exec "$@"`)
+	return "/bin/sh", append([]string{"-c", strings.Join(script, "; "), "sh", name}, args...)
+}
@@ -0,0 +1,212 @@
+package runner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	cgroupMount = "/sys/fs/cgroup"
+	cpuPeriod   = 100000
+)
+
+var (
+	cgroupSeq      atomic.Uint64
+	cgroupWarnOnce sync.Once
+)
+
+// cgroup is a cgroup v2 directory holding exactly one runner's processes.
+type cgroup struct {
+	dir string
+	fd  *os.File
+}
+
+// newCgroup creates a cgroup below parent with the given limits. An empty
+// parent means the cgroup this process lives in.
+func newCgroup(parent string, limits Limits) (*cgroup, error) {
+	own := parent == ""
+	if own {
+		self, err := selfCgroup()
+		if err != nil {
+			return nil, err
+		}
+		parent = self
+	}
+	if err := enableControllers(parent, limits); err != nil {
+		// cgroup v2 only enables controllers for the children of a cgroup
+		// without processes of its own, and this process is one. Moving
+		// it is left to whoever set up the cgroup.
+		if own && errors.Is(err, syscall.EBUSY) {
+			return nil, fmt.Errorf("own cgroup %s holds processes, set -cgroup-parent to a delegated cgroup: %w", parent, err)
+		}
+		return nil, fmt.Errorf("enabling controllers in %s: %w", parent, err)
+	}
+
+	dir := filepath.Join(parent, fmt.Sprintf("ai-sensors-%d-%d", os.Getpid(), cgroupSeq.Add(1)))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, err
+	}
+	cg := &cgroup{dir: dir}
+	if err := writeCgroupLimits(dir, limits); err != nil {
+		cg.remove()
+		return nil, err
+	}
+	fd, err := os.Open(dir)
+	if err != nil {
+		cg.remove()
+		return nil, err
+	}
+	cg.fd = fd
+	return cg, nil
+}
+
+// selfCgroup returns the cgroup v2 directory of the current process.
+func selfCgroup() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupMount, "cgroup.controllers")); err != nil {
+		return "", errors.New("cgroup v2 is not mounted")
+	}
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	for line := range strings.SplitSeq(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return filepath.Join(cgroupMount, path), nil
+		}
+	}
+	return "", errors.New("process is not in a cgroup v2 hierarchy")
+}
+
+func enableControllers(parent string, limits Limits) error {
+	data, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return err
+	}
+	enabled := strings.Fields(string(data))
+
+	var missing []string
+	for _, c := range controllersFor(limits) {
+		if !slices.Contains(enabled, c) {
+			missing = append(missing, "+"+c)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(strings.Join(missing, " ")), 0)
+}
+
+func controllersFor(limits Limits) []string {
+	var cs []string
+	if limits.Memory > 0 {
+		cs = append(cs, "memory")
+	}
+	if limits.CPU > 0 {
+		cs = append(cs, "cpu")
+	}
+	if limits.Processes > 0 {
+		cs = append(cs, "pids")
+	}
+	return cs
+}
+
+func writeCgroupLimits(dir string, limits Limits) error {
+	write := func(file, value string) error {
+		return os.WriteFile(filepath.Join(dir, file), []byte(value), 0)
+	}
+	if limits.Memory > 0 {
+		if err := write("memory.max", strconv.FormatInt(limits.Memory, 10)); err != nil {
+			return err
+		}
+		// Without swap the limit is hit instead of thrashing; kernels
+		// without swap accounting lack the file.
+		_ = write("memory.swap.max", "0")
+	}
+	if limits.CPU > 0 {
+		quota := max(int64(limits.CPU*cpuPeriod), 1000)
+		if err := write("cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod)); err != nil {
+			return err
+		}
+	}
+	if limits.Processes > 0 {
+		if err := write("pids.max", strconv.Itoa(limits.Processes)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// termination reports which limit, if any, the cgroup's processes ran into.
+func (cg *cgroup) termination() Termination {
+	if readEvent(filepath.Join(cg.dir, "memory.events"), "oom_kill") > 0 {
+		return TerminationMemory
+	}
+	if readEvent(filepath.Join(cg.dir, "pids.events"), "max") > 0 {
+		return TerminationProcesses
+	}
+	return ""
+}
+
+func readEvent(path, key string) int64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == key {
+			n, _ := strconv.ParseInt(value, 10, 64)
+			return n
+		}
+	}
+	return 0
+}
+
+// remove kills whatever is left in the cgroup and deletes it.
+func (cg *cgroup) remove() {
+	if cg.fd != nil {
+		cg.fd.Close()
+	}
+	_ = os.WriteFile(filepath.Join(cg.dir, "cgroup.kill"), []byte("1"), 0)
+	for range 50 {
+		if err := os.Remove(cg.dir); err == nil || errors.Is(err, os.ErrNotExist) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	slog.Warn("failed to remove cgroup", "dir", cg.dir)
+}
+
+// prepareLimits places the process in a fresh cgroup when limits call for
+// one. When cgroups are unavailable it returns a nil cgroup and the reason;
+// the rlimit fallback then applies.
+func (r *Runner) prepareLimits(attr *syscall.SysProcAttr) (*cgroup, error) {
+	limits := r.config.Limits
+	if !limits.needsCgroup() {
+		return nil, nil
+	}
+	cg, err := newCgroup(r.config.CgroupParent, limits)
+	if err != nil {
+		cgroupWarnOnce.Do(func() {
+			slog.Warn("cgroup v2 limits unavailable, falling back to rlimits", "error", err)
+		})
+		return nil, fmt.Errorf("%w: %v", ErrLimitsUnenforced, err)
+	}
+	attr.UseCgroupFD = true
+	attr.CgroupFD = int(cg.fd.Fd())
+	return cg, nil
+}
@@ -0,0 +1,90 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_OpenFilesLimit(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := New(Config{
+		Command: "sh",
+		Args:    []string{"-c", "ulimit -n"},
+		Output:  &buf,
+		Limits:  Limits{OpenFiles: 64},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, r.Start(context.Background()))
+	assert.Equal(t, "64", strings.TrimSpace(buf.String()))
+}
+
+func TestWriteCgroupLimits(t *testing.T) {
+	dir := t.TempDir()
+
+	err := writeCgroupLimits(dir, Limits{Memory: 256 << 20, CPU: 1.5, Processes: 100})
+	require.NoError(t, err)
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return string(data)
+	}
+	assert.Equal(t, "268435456", read("memory.max"))
+	assert.Equal(t, "0", read("memory.swap.max"))
+	assert.Equal(t, "150000 100000", read("cpu.max"))
+	assert.Equal(t, "100", read("pids.max"))
+}
+
+func TestCgroupTermination(t *testing.T) {
+	dir := t.TempDir()
+	cg := &cgroup{dir: dir}
+	assert.Empty(t, cg.termination())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pids.events"), []byte("max 3\n"), 0o644))
+	assert.Equal(t, TerminationProcesses, cg.termination())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "memory.events"), []byte("low 0\nhigh 0\nmax 12\noom 1\noom_kill 1\n"), 0o644))
+	assert.Equal(t, TerminationMemory, cg.termination())
+}
+
+func TestRunner_ReportsUnenforcedLimits(t *testing.T) {
+	r, err := New(Config{
+		Command:      "true",
+		Output:       &bytes.Buffer{},
+		Limits:       Limits{Processes: 10},
+		CgroupParent: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, r.Start(context.Background()))
+	assert.ErrorIs(t, r.LimitsError(), ErrLimitsUnenforced)
+}
+
+func TestRunner_CgroupMemoryLimit(t *testing.T) {
+	parent := os.Getenv("AI_SENSORS_TEST_CGROUP")
+	if parent == "" {
+		t.Skip("set AI_SENSORS_TEST_CGROUP to a writable cgroup v2 directory to run")
+	}
+
+	var buf bytes.Buffer
+	r, err := New(Config{
+		Command:      "sh",
+		Args:         []string{"-c", "head -c 200m /dev/zero | tail"},
+		Output:       &buf,
+		Limits:       Limits{Memory: 32 << 20},
+		CgroupParent: parent,
+	})
+	require.NoError(t, err)
+
+	err = r.Start(context.Background())
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.Equal(t, TerminationMemory, r.Termination())
+}
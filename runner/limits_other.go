@@ -0,0 +1,30 @@
+//go:build !linux
+
+package runner
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"syscall"
+)
+
+var limitsWarnOnce sync.Once
+
+type cgroup struct{}
+
+func (cg *cgroup) termination() Termination { return "" }
+
+func (cg *cgroup) remove() {}
+
+// prepareLimits never creates a cgroup outside Linux; only the rlimits and
+// Timeout apply.
+func (r *Runner) prepareLimits(*syscall.SysProcAttr) (*cgroup, error) {
+	if r.config.Limits.CPU > 0 || r.config.Limits.Processes > 0 {
+		limitsWarnOnce.Do(func() {
+			slog.Warn("cpu and process limits are only enforced on Linux")
+		})
+		return nil, fmt.Errorf("%w: cpu and process limits need Linux", ErrLimitsUnenforced)
+	}
+	return nil, nil
+}
@@ -0,0 +1,63 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunner_TimeoutStopsProcess(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := New(Config{
+		Command: "sleep",
+		Args:    []string{"60"},
+		Output:  &buf,
+		Limits:  Limits{Timeout: 100 * time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = r.Start(context.Background())
+
+	assert.ErrorIs(t, err, ErrLimitExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, TerminationTimeout, r.Termination())
+	assert.Equal(t, -1, r.ExitCode())
+}
+
+func TestRunner_TimeoutNotReachedLeavesNoTermination(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := New(Config{
+		Command: "true",
+		Output:  &buf,
+		Limits:  Limits{Timeout: time.Minute},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, r.Start(context.Background()))
+	assert.Empty(t, r.Termination())
+}
+
+func TestRunner_ManualStopIsNotATimeout(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := New(Config{
+		Command: "sleep",
+		Args:    []string{"60"},
+		Output:  &buf,
+		Limits:  Limits{Timeout: 300 * time.Millisecond},
+	})
+	require.NoError(t, err)
+
+	done := make(chan error, 1)
+	go func() { done <- r.Start(context.Background()) }()
+	require.Eventually(t, func() bool { return r.State() == StateRunning }, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, r.Stop())
+	assert.ErrorIs(t, <-done, context.Canceled)
+	time.Sleep(400 * time.Millisecond)
+	assert.Empty(t, r.Termination())
+}
@@ -3,8 +3,10 @@ package runner
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"os/exec"
 	"sync"
 	"syscall"
@@ -34,6 +36,13 @@ type Config struct {
 	Output      io.Writer
 	StopTimeout time.Duration
 	Dir         string
+	// Env is added to the inherited environment, in KEY=VALUE form.
+	Env []string
+	// Limits bounds the resources of the process tree.
+	Limits Limits
+	// CgroupParent is the cgroup v2 directory limited processes are placed
+	// under. Empty means the cgroup of the current process.
+	CgroupParent string
 }
 
 type Runner struct {
@@ -47,6 +56,8 @@ type Runner struct {
 	waitErr   error
 	cancelCtx context.CancelFunc
 	stopped   bool
+	reason    Termination
+	limitsErr error
 }
 
 func New(cfg Config) (*Runner, error) {
@@ -81,14 +92,25 @@ func (r *Runner) Start(ctx context.Context) error {
 	internalCtx, cancel := context.WithCancel(context.Background())
 	r.cancelCtx = cancel
 
-	r.cmd = exec.Command(r.config.Command, r.config.Args...)
+	attr := &syscall.SysProcAttr{Setpgid: true}
+	cg, limitsErr := r.prepareLimits(attr)
+	r.limitsErr = limitsErr
+	name, args := withRlimits(r.config.Limits, cg != nil, r.config.Command, r.config.Args)
+
+	r.cmd = exec.Command(name, args...)
 	r.cmd.Stdout = r.config.Output
 	r.cmd.Stderr = r.config.Output
-	r.cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	r.cmd.SysProcAttr = attr
 	r.cmd.Dir = r.config.Dir
+	if len(r.config.Env) > 0 {
+		r.cmd.Env = append(os.Environ(), r.config.Env...)
+	}
 
 	if err := r.cmd.Start(); err != nil {
 		r.mu.Unlock()
+		if cg != nil {
+			cg.remove()
+		}
 		if errors.Is(err, exec.ErrNotFound) {
 			return exec.ErrNotFound
 		}
@@ -98,6 +120,18 @@ func (r *Runner) Start(ctx context.Context) error {
 	r.state = StateRunning
 	r.mu.Unlock()
 
+	if r.config.Limits.Timeout > 0 {
+		timer := time.AfterFunc(r.config.Limits.Timeout, func() {
+			r.mu.Lock()
+			if !r.stopped && r.reason == "" {
+				r.reason = TerminationTimeout
+			}
+			r.mu.Unlock()
+			r.doStop()
+		})
+		defer timer.Stop()
+	}
+
 	processDone := make(chan error, 1)
 	go func() {
 		processDone <- r.cmd.Wait()
@@ -113,13 +147,28 @@ func (r *Runner) Start(ctx context.Context) error {
 
 	err := <-processDone
 
+	var cgReason Termination
+	if cg != nil {
+		if !r.cmd.ProcessState.Success() {
+			cgReason = cg.termination()
+		}
+		cg.remove()
+	}
+
 	r.mu.Lock()
+	if r.reason == "" {
+		r.reason = cgReason
+	}
 	r.state = StateStopped
 	r.waitErr = err
 	wasStopped := r.stopped
+	reason := r.reason
 	close(r.waitDone)
 	r.mu.Unlock()
 
+	if reason != "" {
+		return fmt.Errorf("%w: %s", ErrLimitExceeded, reason)
+	}
 	if wasStopped || ctx.Err() == context.Canceled {
 		return context.Canceled
 	}
@@ -178,3 +227,40 @@ func (r *Runner) Wait() error {
 	<-r.waitDone
 	return r.waitErr
 }
+
+// Pid returns the process ID, which is also its process group ID, or 0
+// before the process has started.
+func (r *Runner) Pid() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.state != StateRunning || r.cmd == nil || r.cmd.Process == nil {
+		return 0
+	}
+	return r.cmd.Process.Pid
+}
+
+// Termination returns the limit that ended the process, if any.
+func (r *Runner) Termination() Termination {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.reason
+}
+
+// LimitsError reports why the process runs without the cgroup its limits
+// asked for, wrapping ErrLimitsUnenforced, or nil.
+func (r *Runner) LimitsError() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.limitsErr
+}
+
+// ExitCode returns the exit code of the finished process, or -1 if it has not
+// exited yet, failed to start or was terminated by a signal.
+func (r *Runner) ExitCode() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.state != StateStopped || r.cmd == nil || r.cmd.ProcessState == nil {
+		return -1
+	}
+	return r.cmd.ProcessState.ExitCode()
+}
@@ -441,3 +441,36 @@ func TestRunner_Wait(t *testing.T) {
 	assert.Equal(t, StateStopped, r.State())
 	assert.Equal(t, "done\n", buf.String())
 }
+
+func TestRunner_ExitCode(t *testing.T) {
+	var buf bytes.Buffer
+	r, err := New(Config{
+		Command: "sh",
+		Args:    []string{"-c", "exit 7"},
+		Output:  &buf,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, -1, r.ExitCode())
+
+	err = r.Start(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, 7, r.ExitCode())
+}
+
+func TestRunner_EnvExtendsInheritedEnvironment(t *testing.T) {
+	t.Setenv("RUNNER_INHERITED", "kept")
+	var buf bytes.Buffer
+	r, err := New(Config{
+		Command: "sh",
+		Args:    []string{"-c", `echo "$RUNNER_INHERITED $RUNNER_EXTRA"`},
+		Output:  &buf,
+		Env:     []string{"RUNNER_EXTRA=added"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, r.Start(context.Background()))
+
+	assert.Equal(t, "kept added\n", buf.String())
+}
@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cloud-gt/ai-sensors/auth"
+)
+
+type tokenContextKey struct{}
+
+// TokenFromContext returns the token that authenticated the request, if any.
+func TokenFromContext(ctx context.Context) (auth.Token, bool) {
+	t, ok := ctx.Value(tokenContextKey{}).(auth.Token)
+	return t, ok
+}
+
+// requiredScope maps a request to the scope it needs. Reads need read;
+// creating or deleting a definition needs define, since a definition is an
+// arbitrary shell command; everything else changes process state and needs
+// control.
+func requiredScope(r *http.Request) auth.Scope {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return auth.ScopeRead
+	case http.MethodDelete:
+		return auth.ScopeDefine
+	}
+	if strings.TrimSuffix(r.URL.Path, "/") == "/commands" {
+		return auth.ScopeDefine
+	}
+	return auth.ScopeControl
+}
+
+// bearerToken extracts the token from the Authorization header.
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if ok && strings.EqualFold(scheme, "Bearer") {
+		return strings.TrimSpace(token)
+	}
+	return ""
+}
+
+const accessTokenParam = "access_token"
+
+// isStreamRoute reports whether path serves server-sent events, which
+// EventSource consumes without being able to set headers.
+func isStreamRoute(path string) bool {
+	return path == "/events" ||
+		strings.HasPrefix(path, "/commands/") && strings.HasSuffix(path, "/output/stream")
+}
+
+// queryToken removes the access_token query parameter from every request
+// so that it is never logged. On GET requests to streaming routes it is
+// moved to the Authorization header, unless the request already has one;
+// elsewhere it is ignored.
+func queryToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if !query.Has(accessTokenParam) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		token := query.Get(accessTokenParam)
+		query.Del(accessTokenParam)
+
+		r = r.Clone(r.Context())
+		r.URL.RawQuery = query.Encode()
+		r.RequestURI = r.URL.RequestURI()
+		if r.Method == http.MethodGet && isStreamRoute(r.URL.Path) && token != "" && r.Header.Get("Authorization") == "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireToken rejects requests without a token carrying the scope the
+// request needs. While there is no store or it holds no tokens, only
+// requests a web page cannot make pass; see localRequest.
+func requireToken(tokens *auth.TokenStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			enabled := false
+			if tokens != nil {
+				var err error
+				enabled, err = tokens.Enabled()
+				if err != nil {
+					slog.Error("failed to read token file", "path", tokens.Path(), "error", err)
+					writeError(w, http.StatusInternalServerError, "internal server error")
+					return
+				}
+			}
+			if !enabled {
+				if !localRequest(r) {
+					writeError(w, http.StatusForbidden, "unauthenticated requests must come from a loopback host")
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			secret := bearerToken(r)
+			if secret == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="ai-sensors"`)
+				writeError(w, http.StatusUnauthorized, "missing bearer token")
+				return
+			}
+			token, err := tokens.Authenticate(secret)
+			if err != nil {
+				if !errors.Is(err, auth.ErrTokenNotFound) {
+					slog.Error("failed to read token file", "path", tokens.Path(), "error", err)
+				}
+				w.Header().Set("WWW-Authenticate", `Bearer realm="ai-sensors", error="invalid_token"`)
+				writeError(w, http.StatusUnauthorized, "invalid token")
+				return
+			}
+
+			scope := requiredScope(r)
+			if !token.Allows(scope) {
+				writeError(w, http.StatusForbidden, "token lacks scope "+string(scope))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tokenContextKey{}, token)))
+		})
+	}
+}
+
+// localRequest reports whether a request without a token can be trusted to
+// come from a local client. Unix socket peers are local. Over TCP the peer
+// must be a loopback address, which keeps out other hosts, and the Host must
+// be a loopback name, and so must the Origin when there is one, which keeps
+// out web pages a local browser was tricked into loading, DNS rebinding
+// included.
+func localRequest(r *http.Request) bool {
+	if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok && addr.Network() == "unix" {
+		return true
+	}
+	if !loopbackHost(r.RemoteAddr) || !loopbackHost(r.Host) {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && loopbackHost(u.Host)
+}
+
+// loopbackHost reports whether host, with or without a port, names the
+// local machine.
+func loopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// requireJSON rejects POST requests not declared as JSON. Browsers only send
+// other content types cross-origin without a preflight, so this keeps a web
+// page from starting commands through a visitor's browser.
+func requireJSON(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
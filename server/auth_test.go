@@ -0,0 +1,214 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/auth"
+	"github.com/cloud-gt/ai-sensors/client"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthTestServer(t *testing.T) (*Server, *testClient, *auth.TokenStore) {
+	t.Helper()
+	tokens := auth.NewTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+	store := command.NewStore(command.NewMemoryRepository())
+	mgr := manager.New(store)
+	srv := New(store, mgr, WithTokens(tokens))
+	return srv, newTestClient(t, srv), tokens
+}
+
+func createToken(t *testing.T, tokens *auth.TokenStore, name string, scopes ...auth.Scope) string {
+	t.Helper()
+	_, secret, err := tokens.Create(name, scopes)
+	require.NoError(t, err)
+	return secret
+}
+
+func TestAuth_OpenWithoutTokens(t *testing.T) {
+	_, tc, _ := newAuthTestServer(t)
+
+	_, err := tc.ListCommands(t.Context())
+
+	assert.NoError(t, err)
+}
+
+func TestAuth_RequiresBearerToken(t *testing.T) {
+	_, tc, tokens := newAuthTestServer(t)
+	createToken(t, tokens, "admin", auth.AllScopes...)
+
+	resp := tc.Request(http.MethodGet, "/commands", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = tc.WithToken("ais_wrong").Request(http.MethodGet, "/commands", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = tc.Request(http.MethodGet, "/groups", nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAuth_Scopes(t *testing.T) {
+	_, tc, tokens := newAuthTestServer(t)
+	admin := tc.WithToken(createToken(t, tokens, "admin", auth.AllScopes...))
+	reader := tc.WithToken(createToken(t, tokens, "reader", auth.ScopeRead))
+	operator := tc.WithToken(createToken(t, tokens, "operator", auth.ScopeRead, auth.ScopeControl))
+
+	resp := operator.Request(http.MethodPost, "/commands", map[string]string{"name": "x", "command": "true", "work_dir": "/tmp"})
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "control cannot define commands")
+
+	created, err := admin.CreateCommand(t.Context(), command.Command{Name: "echo", Command: "echo hi", WorkDir: "/tmp"})
+	require.NoError(t, err)
+
+	_, err = reader.GetCommand(t.Context(), created.ID)
+	assert.NoError(t, err)
+
+	_, err = reader.StartCommand(t.Context(), created.ID)
+	assert.ErrorIs(t, err, client.ErrForbidden, "read cannot start commands")
+
+	resp = reader.Request(http.MethodPost, "/commands/"+created.ID.String()+"/start", nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "read cannot start commands")
+
+	resp = operator.Request(http.MethodPost, "/commands/"+created.ID.String()+"/run?wait=true", nil)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = operator.Request(http.MethodDelete, "/commands/"+created.ID.String(), nil)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "control cannot delete commands")
+
+	resp = admin.Request(http.MethodDelete, "/commands/"+created.ID.String(), nil)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestAuth_RevokedTokenIsRejected(t *testing.T) {
+	_, tc, tokens := newAuthTestServer(t)
+	secret := createToken(t, tokens, "ci", auth.ScopeRead)
+	createToken(t, tokens, "admin", auth.AllScopes...)
+
+	_, err := tc.WithToken(secret).ListCommands(t.Context())
+	require.NoError(t, err)
+
+	require.NoError(t, tokens.Revoke("ci"))
+
+	_, err = tc.WithToken(secret).ListCommands(t.Context())
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+}
+
+func TestAuth_AccessTokenQueryOnlyForStreams(t *testing.T) {
+	_, tc, tokens := newAuthTestServer(t)
+	secret := createToken(t, tokens, "admin", auth.AllScopes...)
+
+	resp := tc.Request(http.MethodGet, "/commands/"+uuid.NewString()+"/output/stream?access_token="+secret, nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = tc.Request(http.MethodGet, "/commands?access_token="+secret, nil)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp = tc.Request(http.MethodPost, "/commands?access_token="+secret, map[string]string{"name": "x", "command": "true", "work_dir": "/tmp"})
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestQueryToken_StripsParameter(t *testing.T) {
+	for _, tt := range []struct {
+		target, wantURI, wantAuth string
+	}{
+		{"/events?type=run.started&access_token=s3cr3t", "/events?type=run.started", "Bearer s3cr3t"},
+		{"/commands/abc/output/stream?access_token=s3cr3t&lines=5", "/commands/abc/output/stream?lines=5", "Bearer s3cr3t"},
+		{"/commands?access_token=s3cr3t", "/commands", ""},
+	} {
+		var gotURI, gotAuth string
+		h := queryToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotURI, gotAuth = r.RequestURI, r.Header.Get("Authorization")
+		}))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.target, nil))
+		assert.Equal(t, tt.wantURI, gotURI, tt.target)
+		assert.Equal(t, tt.wantAuth, gotAuth, tt.target)
+	}
+}
+
+func TestAuth_UnauthenticatedOnlyFromLoopback(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, tt := range []struct {
+		remote, host, origin string
+		want                 int
+	}{
+		{"127.0.0.1:50000", "127.0.0.1:7890", "", http.StatusOK},
+		{"127.0.0.1:50000", "localhost:7890", "http://localhost:7890", http.StatusOK},
+		{"[::1]:50000", "[::1]:7890", "", http.StatusOK},
+		{"127.0.0.1:50000", "attacker.example:7890", "", http.StatusForbidden},
+		{"127.0.0.1:50000", "127.0.0.1:7890", "https://attacker.example", http.StatusForbidden},
+		{"127.0.0.1:50000", "127.0.0.1:7890", "null", http.StatusForbidden},
+		{"192.0.2.10:50000", "127.0.0.1:7890", "", http.StatusForbidden},
+		{"[2001:db8::1]:50000", "localhost:7890", "", http.StatusForbidden},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/commands", nil)
+		req.RemoteAddr = tt.remote
+		req.Host = tt.host
+		if tt.origin != "" {
+			req.Header.Set("Origin", tt.origin)
+		}
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, req)
+		assert.Equal(t, tt.want, rec.Code, "remote %q host %q origin %q", tt.remote, tt.host, tt.origin)
+	}
+}
+
+func TestAuth_TokenAllowsAnyHost(t *testing.T) {
+	srv, _, tokens := newAuthTestServer(t)
+	secret := createToken(t, tokens, "reader", auth.ScopeRead)
+
+	req := httptest.NewRequest(http.MethodGet, "/commands", nil)
+	req.Host = "ai-sensors.internal:7890"
+	req.Header.Set("Authorization", "Bearer "+secret)
+	rec := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestRequireJSON(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		req := httptest.NewRequest(http.MethodPost, "/commands", strings.NewReader(`{"name":"x","command":"true","work_dir":"/tmp"}`))
+		req.RemoteAddr = "127.0.0.1:50000"
+		req.Host = "127.0.0.1:7890"
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		rec := httptest.NewRecorder()
+		srv.Router().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code, contentType)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/commands", strings.NewReader(`{"name":"x","command":"true","work_dir":"/tmp"}`))
+	req.RemoteAddr = "127.0.0.1:50000"
+	req.Host = "127.0.0.1:7890"
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	rec := httptest.NewRecorder()
+	srv.Router().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestRequiredScope(t *testing.T) {
+	for _, tt := range []struct {
+		method, path string
+		want         auth.Scope
+	}{
+		{http.MethodGet, "/commands", auth.ScopeRead},
+		{http.MethodGet, "/commands/abc/output", auth.ScopeRead},
+		{http.MethodPost, "/commands", auth.ScopeDefine},
+		{http.MethodPost, "/commands/", auth.ScopeDefine},
+		{http.MethodDelete, "/commands/abc", auth.ScopeDefine},
+		{http.MethodPost, "/commands/abc/start", auth.ScopeControl},
+		{http.MethodPost, "/groups/web/stop", auth.ScopeControl},
+	} {
+		req, _ := http.NewRequest(tt.method, tt.path, nil)
+		assert.Equal(t, tt.want, requiredScope(req), tt.method+" "+tt.path)
+	}
+}
@@ -1,13 +1,24 @@
 package server
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/cloud-gt/ai-sensors/buffer"
 	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/diagnostics"
+	"github.com/cloud-gt/ai-sensors/digest"
 	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/outputdiff"
+	"github.com/cloud-gt/ai-sensors/policy"
+	"github.com/cloud-gt/ai-sensors/procstats"
+	"github.com/cloud-gt/ai-sensors/redact"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
@@ -35,25 +46,54 @@ func (api *CommandsAPI) Router() chi.Router {
 		r.Post("/stop", api.handleStop)
 		r.Get("/status", api.handleStatus)
 		r.Get("/output", api.handleOutput)
+		r.Get("/output/stream", api.handleOutputStream)
+		r.Get("/digest", api.handleDigest)
+		r.Get("/runs", api.handleRuns)
+		r.Get("/runs/diff", api.handleRunsDiff)
+		r.Post("/run", api.handleRun)
+		r.Get("/tests", api.handleTests)
+		r.Get("/diagnostics", api.handleDiagnostics)
+		r.Get("/metrics", api.handleMetrics)
 	})
 	return r
 }
 
 func (api *CommandsAPI) handleList(w http.ResponseWriter, r *http.Request) {
-	commands, err := api.store.List()
+	var commands []command.Command
+	var err error
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		commands, err = api.store.ListByTag(tag)
+	} else {
+		commands, err = api.store.List()
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
+	for i := range commands {
+		commands[i] = commands[i].Redacted()
+	}
 	writeJSON(w, http.StatusOK, map[string]any{"commands": commands})
 }
 
 func (api *CommandsAPI) handleCreate(w http.ResponseWriter, r *http.Request) {
 	var req struct {
-		Name    string `json:"name"`
-		Command string `json:"command"`
-		WorkDir string `json:"work_dir"`
+		Name      string                    `json:"name"`
+		Command   string                    `json:"command"`
+		WorkDir   string                    `json:"work_dir"`
+		Readiness *command.Readiness        `json:"readiness"`
+		DependsOn []uuid.UUID               `json:"depends_on"`
+		Tags      []string                  `json:"tags"`
+		Autostart bool                      `json:"autostart"`
+		Watch     *command.Watch            `json:"watch"`
+		Matchers  []diagnostics.Definition  `json:"matchers"`
+		Tests     *command.TestResults      `json:"tests"`
+		Normalize *outputdiff.Normalization `json:"normalize"`
+		Dedup     bool                      `json:"dedup"`
+		Env       []command.EnvVar          `json:"env"`
+		Redact    *redact.Config            `json:"redact"`
+		Limits    *command.Limits           `json:"limits"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -81,16 +121,47 @@ func (api *CommandsAPI) handleCreate(w http.ResponseWriter, r *http.Request) {
 	}
 
 	cmd, err := api.store.Create(command.Command{
-		Name:    req.Name,
-		Command: req.Command,
-		WorkDir: req.WorkDir,
+		Name:      req.Name,
+		Command:   req.Command,
+		WorkDir:   req.WorkDir,
+		Readiness: req.Readiness,
+		DependsOn: req.DependsOn,
+		Tags:      req.Tags,
+		Autostart: req.Autostart,
+		Watch:     req.Watch,
+		Matchers:  req.Matchers,
+		Tests:     req.Tests,
+		Normalize: req.Normalize,
+		Dedup:     req.Dedup,
+		Env:       req.Env,
+		Redact:    req.Redact,
+		Limits:    req.Limits,
 	})
 	if err != nil {
+		if errors.Is(err, command.ErrInvalidReadiness) ||
+			errors.Is(err, command.ErrUnknownDependency) ||
+			errors.Is(err, command.ErrDependencyCycle) ||
+			errors.Is(err, command.ErrInvalidTag) ||
+			errors.Is(err, command.ErrInvalidWatch) ||
+			errors.Is(err, command.ErrInvalidTestResults) ||
+			errors.Is(err, outputdiff.ErrInvalidNormalization) ||
+			errors.Is(err, command.ErrInvalidEnv) ||
+			errors.Is(err, command.ErrInvalidLimits) ||
+			errors.Is(err, redact.ErrInvalidRedaction) ||
+			errors.Is(err, diagnostics.ErrInvalidMatcher) ||
+			errors.Is(err, diagnostics.ErrUnknownMatcher) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, policy.ErrDenied) || errors.Is(err, policy.ErrReadOnly) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
-	writeJSON(w, http.StatusCreated, cmd)
+	writeJSON(w, http.StatusCreated, cmd.Redacted())
 }
 
 func (api *CommandsAPI) handleGet(w http.ResponseWriter, r *http.Request) {
@@ -110,7 +181,7 @@ func (api *CommandsAPI) handleGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, cmd)
+	writeJSON(w, http.StatusOK, cmd.Redacted())
 }
 
 func (api *CommandsAPI) handleDelete(w http.ResponseWriter, r *http.Request) {
@@ -121,7 +192,7 @@ func (api *CommandsAPI) handleDelete(w http.ResponseWriter, r *http.Request) {
 	}
 
 	status, err := api.manager.Status(id)
-	if err == nil && status == manager.StatusRunning {
+	if err == nil && status.Active() {
 		writeError(w, http.StatusConflict, "cannot delete running command")
 		return
 	}
@@ -132,6 +203,14 @@ func (api *CommandsAPI) handleDelete(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusNotFound, "command not found")
 			return
 		}
+		if errors.Is(err, command.ErrDependencyInUse) {
+			writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, policy.ErrReadOnly) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
@@ -148,10 +227,18 @@ func (api *CommandsAPI) handleStart(w http.ResponseWriter, r *http.Request) {
 
 	started, err := api.manager.Start(r.Context(), id)
 	if err != nil {
+		if errors.Is(err, manager.ErrDependencyNotReady) {
+			writeError(w, http.StatusFailedDependency, err.Error())
+			return
+		}
 		if errors.Is(err, manager.ErrCommandNotFound) {
 			writeError(w, http.StatusNotFound, "command not found")
 			return
 		}
+		if errors.Is(err, policy.ErrDenied) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "internal server error")
 		return
 	}
@@ -196,7 +283,11 @@ func (api *CommandsAPI) handleStatus(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{"status": string(status)})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":      string(status),
+		"autostarted": api.manager.Autostarted(id),
+		"watching":    api.manager.Watching(id),
+	})
 }
 
 func (api *CommandsAPI) handleOutput(w http.ResponseWriter, r *http.Request) {
@@ -206,6 +297,20 @@ func (api *CommandsAPI) handleOutput(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Query().Get("entries") == "true" {
+		entries, err := api.manager.OutputEntries(id)
+		if err != nil {
+			if errors.Is(err, manager.ErrNotRunning) {
+				writeError(w, http.StatusNotFound, "command not running")
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "internal server error")
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]buffer.Entry{"entries": entries})
+		return
+	}
+
 	linesParam := r.URL.Query().Get("lines")
 	var lines []string
 
@@ -243,6 +348,362 @@ func (api *CommandsAPI) handleOutput(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string][]string{"lines": lines})
 }
 
+// handleOutputStream streams the output of the current run as server-sent
+// events: the last lines=N lines, then each new line as it is written, one
+// "line" event per line. An "end" event follows once the run ends or the
+// client falls too far behind.
+func (api *CommandsAPI) handleOutputStream(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command ID")
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	n := 0
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err = strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "lines must be a positive integer")
+			return
+		}
+	}
+
+	backlog, lines, stop, err := api.manager.FollowOutput(id, n)
+	if err != nil {
+		if errors.Is(err, manager.ErrNotRunning) {
+			writeError(w, http.StatusNotFound, "command not running")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	defer stop()
+
+	startStream(w, flusher)
+	for _, line := range backlog {
+		writeLineEvent(w, line)
+	}
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case line, ok := <-lines:
+			if !ok {
+				fmt.Fprint(w, "event: end\ndata: {}\n\n")
+				flusher.Flush()
+				return
+			}
+			if err := writeLineEvent(w, line); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// handleDigest returns a condensed view of the output sized for an agent's
+// context window. With run=N it digests that run's retained output instead
+// of the current buffer.
+func (api *CommandsAPI) handleDigest(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command ID")
+		return
+	}
+
+	maxChars := digest.DefaultMaxChars
+	if s := r.URL.Query().Get("max_chars"); s != "" {
+		maxChars, err = strconv.Atoi(s)
+		if err != nil || maxChars < 1 {
+			writeError(w, http.StatusBadRequest, "max_chars must be a positive integer")
+			return
+		}
+	}
+
+	var lines []string
+	if s := r.URL.Query().Get("run"); s != "" {
+		number, convErr := strconv.Atoi(s)
+		if convErr != nil || number < 1 {
+			writeError(w, http.StatusBadRequest, "invalid run parameter")
+			return
+		}
+		lines, err = api.manager.RunOutput(id, number)
+	} else {
+		lines, err = api.manager.Output(id)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, manager.ErrNotRunning):
+			writeError(w, http.StatusNotFound, "command not running")
+		case errors.Is(err, manager.ErrRunNotFound):
+			writeError(w, http.StatusNotFound, "run not found")
+		default:
+			writeError(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, digest.Build(lines, maxChars))
+}
+
+// handleMetrics returns process resource usage. since (RFC 3339) limits the
+// samples to those taken after it, so the dashboard can poll incrementally.
+func (api *CommandsAPI) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command ID")
+		return
+	}
+
+	var since time.Time
+	if s := r.URL.Query().Get("since"); s != "" {
+		since, err = time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+	}
+
+	latest, samples, err := api.manager.Metrics(id, since)
+	if err != nil {
+		switch {
+		case errors.Is(err, manager.ErrNotRunning):
+			writeError(w, http.StatusNotFound, "command not running")
+		case errors.Is(err, procstats.ErrUnsupported):
+			writeError(w, http.StatusNotImplemented, err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"latest": latest, "samples": samples})
+}
+
+func (api *CommandsAPI) handleRuns(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command ID")
+		return
+	}
+
+	runs, err := api.manager.Runs(id)
+	if err != nil {
+		if errors.Is(err, manager.ErrNotRunning) {
+			writeError(w, http.StatusNotFound, "command not running")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]manager.Run{"runs": runs})
+}
+
+// handleRunsDiff diffs the output of two runs, by default the latest run
+// against the one before it.
+func (api *CommandsAPI) handleRunsDiff(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command ID")
+		return
+	}
+
+	var numbers [2]int
+	for i, param := range []string{"from", "to"} {
+		s := r.URL.Query().Get(param)
+		if s == "" {
+			continue
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "invalid "+param+" parameter")
+			return
+		}
+		numbers[i] = n
+	}
+
+	diff, err := api.manager.DiffRuns(id, numbers[0], numbers[1])
+	if err != nil {
+		switch {
+		case errors.Is(err, manager.ErrCommandNotFound):
+			writeError(w, http.StatusNotFound, "command not found")
+		case errors.Is(err, manager.ErrNotRunning):
+			writeError(w, http.StatusNotFound, "command not running")
+		case errors.Is(err, manager.ErrRunNotFound):
+			writeError(w, http.StatusNotFound, "run not found")
+		default:
+			writeError(w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, diff)
+}
+
+const (
+	defaultRunTimeout   = 10 * time.Minute
+	defaultRunTailLines = 100
+)
+
+// handleRun starts a command and, with wait=true, blocks until it exits or the
+// timeout elapses, in which case the command is stopped.
+func (api *CommandsAPI) handleRun(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command ID")
+		return
+	}
+
+	query := r.URL.Query()
+	wait := query.Get("wait") == "true"
+
+	timeout := defaultRunTimeout
+	if s := query.Get("timeout"); s != "" {
+		timeout, err = time.ParseDuration(s)
+		if err != nil || timeout <= 0 {
+			writeError(w, http.StatusBadRequest, "timeout must be a positive duration")
+			return
+		}
+	}
+
+	tail := defaultRunTailLines
+	if s := query.Get("lines"); s != "" {
+		tail, err = strconv.Atoi(s)
+		if err != nil || tail < 0 {
+			writeError(w, http.StatusBadRequest, "lines must be a positive integer")
+			return
+		}
+	}
+
+	// The timeout also bounds starting dependencies and waiting for them to
+	// become ready.
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	started, err := api.manager.Start(ctx, id)
+	if err != nil {
+		if errors.Is(err, manager.ErrDependencyNotReady) || errors.Is(err, context.DeadlineExceeded) {
+			writeError(w, http.StatusFailedDependency, err.Error())
+			return
+		}
+		if errors.Is(err, manager.ErrCommandNotFound) {
+			writeError(w, http.StatusNotFound, "command not found")
+			return
+		}
+		if errors.Is(err, policy.ErrDenied) {
+			writeError(w, http.StatusForbidden, err.Error())
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !started {
+		writeError(w, http.StatusConflict, "command is already running")
+		return
+	}
+
+	if !wait {
+		writeJSON(w, http.StatusAccepted, map[string]bool{"started": true})
+		return
+	}
+
+	run, err := api.manager.Wait(ctx, id)
+	timedOut := errors.Is(err, context.DeadlineExceeded)
+	if timedOut {
+		if err := api.manager.Stop(id); err != nil {
+			slog.Warn("failed to stop command after run timeout", "id", id, "error", err)
+		}
+		run, err = api.manager.Wait(r.Context(), id)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	// Read the output of this run rather than the current buffer, which a
+	// new run may already have replaced.
+	output, err := api.manager.RunOutput(id, run.Number)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	output = output[max(0, len(output)-tail):]
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"run":         run,
+		"exit_code":   run.ExitCode,
+		"duration_ms": run.Duration().Milliseconds(),
+		"timed_out":   timedOut,
+		"output":      output,
+	})
+}
+
+func (api *CommandsAPI) handleTests(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command ID")
+		return
+	}
+
+	report, err := api.manager.TestReport(id)
+	if err != nil {
+		if errors.Is(err, manager.ErrNotRunning) {
+			writeError(w, http.StatusNotFound, "command not running")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	if r.URL.Query().Get("failed") == "true" {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"format":   report.Format,
+			"summary":  report.Summary,
+			"failures": report.Failures(),
+			"error":    report.Error,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (api *CommandsAPI) handleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid command ID")
+		return
+	}
+
+	severity := diagnostics.Severity(r.URL.Query().Get("severity"))
+	switch severity {
+	case "", diagnostics.SeverityError, diagnostics.SeverityWarning, diagnostics.SeverityInfo:
+	default:
+		writeError(w, http.StatusBadRequest, "severity must be error, warning or info")
+		return
+	}
+
+	diags, err := api.manager.Diagnostics(id, severity)
+	if err != nil {
+		if errors.Is(err, manager.ErrNotRunning) {
+			writeError(w, http.StatusNotFound, "command not running")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string][]diagnostics.Diagnostic{"diagnostics": diags})
+}
+
 func parseUUID(s string) (uuid.UUID, error) {
 	return uuid.Parse(s)
 }
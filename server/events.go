@@ -0,0 +1,141 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/google/uuid"
+)
+
+const (
+	eventsBuffer    = 64
+	eventsHeartbeat = 15 * time.Second
+)
+
+// WithEvents serves the events published to bus as a server-sent event
+// stream at /events.
+func WithEvents(bus *events.Bus) Option {
+	return func(s *Server) {
+		s.events = bus
+	}
+}
+
+type eventsAPI struct {
+	bus       *events.Bus
+	heartbeat time.Duration
+}
+
+// handleEvents streams events as they are published. The command query
+// parameter restricts the stream to one command and type to a
+// comma-separated list of event types. A client reconnecting with
+// Last-Event-ID first receives the recent events it missed. The stream
+// ends if the client falls too far behind; it is expected to reconnect.
+func (a *eventsAPI) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming unsupported")
+		return
+	}
+
+	query := r.URL.Query()
+	var commandID uuid.UUID
+	if v := query.Get("command"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid command ID")
+			return
+		}
+		commandID = id
+	}
+	types := make(map[events.Type]bool)
+	if v := query.Get("type"); v != "" {
+		for _, t := range strings.Split(v, ",") {
+			types[events.Type(strings.TrimSpace(t))] = true
+		}
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = query.Get("last_event_id")
+	}
+	var after uint64
+	if lastID != "" {
+		id, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid Last-Event-ID")
+			return
+		}
+		after = id
+	}
+
+	sub := a.bus.Subscribe(eventsBuffer, after)
+	defer sub.Close()
+
+	startStream(w, flusher)
+
+	heartbeat := time.NewTicker(a.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if commandID != uuid.Nil && e.CommandID != commandID {
+				continue
+			}
+			if len(types) > 0 && !types[e.Type] {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// lineBreaks normalises the line terminators server-sent events recognise.
+var lineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// writeLineEvent writes line as a "line" event. A carriage return or newline
+// inside line would end the data field early, so each piece is sent as its
+// own data field, which clients join back with newlines.
+func writeLineEvent(w io.Writer, line string) error {
+	var b strings.Builder
+	b.WriteString("event: line\n")
+	for _, part := range strings.Split(lineBreaks.Replace(line), "\n") {
+		b.WriteString("data: ")
+		b.WriteString(part)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// startStream sends the headers of a server-sent event stream.
+func startStream(w http.ResponseWriter, flusher http.Flusher) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+}
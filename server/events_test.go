@@ -0,0 +1,187 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/client"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseFrame struct {
+	id    string
+	event string
+	data  string
+}
+
+// openEvents connects to /events and returns a function reading the next
+// frame, skipping comments.
+func openEvents(t *testing.T, url string, header http.Header) func() sseFrame {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	return func() sseFrame {
+		t.Helper()
+		var f sseFrame
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case line, ok := <-lines:
+				require.True(t, ok, "stream closed")
+				switch {
+				case line == "" && f.event != "":
+					return f
+				case strings.HasPrefix(line, "id: "):
+					f.id = strings.TrimPrefix(line, "id: ")
+				case strings.HasPrefix(line, "event: "):
+					f.event = strings.TrimPrefix(line, "event: ")
+				case strings.HasPrefix(line, "data: "):
+					f.data = strings.TrimPrefix(line, "data: ")
+				}
+			case <-timeout:
+				require.FailNow(t, "no event")
+			}
+		}
+	}
+}
+
+func newEventsTestServer(t *testing.T) (*httptest.Server, *command.Store, *manager.Manager) {
+	t.Helper()
+	bus := events.NewBus()
+	store := command.NewStore(command.NewMemoryRepository(), command.WithEvents(bus))
+	mgr := manager.New(store, manager.WithEvents(bus))
+	ts := httptest.NewServer(New(store, mgr, WithEvents(bus)).Router())
+	t.Cleanup(ts.Close)
+	return ts, store, mgr
+}
+
+func TestEvents_Stream(t *testing.T) {
+	ts, store, mgr := newEventsTestServer(t)
+	next := openEvents(t, ts.URL+"/events", nil)
+
+	cmd, err := store.Create(command.Command{Name: "hello", Command: "echo hi", WorkDir: "/tmp", Env: []command.EnvVar{{Name: "TOKEN", Value: "s3cr3t", Secret: true}}})
+	require.NoError(t, err)
+
+	f := next()
+	assert.Equal(t, "command.created", f.event)
+	var created struct {
+		ID        uint64          `json:"id"`
+		CommandID string          `json:"command_id"`
+		Data      command.Command `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(f.data), &created))
+	assert.Equal(t, f.id, strconv.FormatUint(created.ID, 10))
+	assert.Equal(t, cmd.ID.String(), created.CommandID)
+	assert.NotContains(t, f.data, "s3cr3t")
+
+	_, err = mgr.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "run.started", next().event)
+
+	f = next()
+	assert.Equal(t, "run.exited", f.event)
+	assert.Contains(t, f.data, `"exit_code":0`)
+}
+
+func TestEvents_Filters(t *testing.T) {
+	ts, store, _ := newEventsTestServer(t)
+
+	a, err := store.Create(command.Command{Name: "a", Command: "true", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	next := openEvents(t, ts.URL+"/events?command="+a.ID.String()+"&type=command.deleted", nil)
+
+	_, err = store.Create(command.Command{Name: "b", Command: "true", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	require.NoError(t, store.Delete(a.ID))
+
+	f := next()
+	assert.Equal(t, "command.deleted", f.event)
+	assert.Contains(t, f.data, a.ID.String())
+}
+
+func TestEvents_ResumesFromLastEventID(t *testing.T) {
+	ts, store, _ := newEventsTestServer(t)
+
+	for _, name := range []string{"a", "b", "c"} {
+		_, err := store.Create(command.Command{Name: name, Command: "true", WorkDir: "/tmp"})
+		require.NoError(t, err)
+	}
+
+	next := openEvents(t, ts.URL+"/events", http.Header{"Last-Event-Id": {"1"}})
+	assert.Equal(t, "2", next().id)
+	assert.Equal(t, "3", next().id)
+}
+
+func TestEvents_Client(t *testing.T) {
+	bus := events.NewBus()
+	store := command.NewStore(command.NewMemoryRepository(), command.WithEvents(bus))
+	tc := newTestClient(t, New(store, manager.New(store, manager.WithEvents(bus)), WithEvents(bus)))
+
+	a, err := tc.CreateCommand(t.Context(), command.Command{Name: "a", Command: "true", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	_, err = tc.CreateCommand(t.Context(), command.Command{Name: "b", Command: "true", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	require.NoError(t, tc.DeleteCommand(t.Context(), a.ID))
+
+	var got []client.Event
+	err = tc.Events(t.Context(), client.EventFilter{Command: a.ID, Types: []events.Type{events.CommandDeleted}, After: 1}, func(e client.Event) error {
+		got = append(got, e)
+		return client.ErrStop
+	})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	assert.Equal(t, events.CommandDeleted, got[0].Type)
+	assert.Equal(t, a.ID, got[0].CommandID)
+	assert.Equal(t, uint64(3), got[0].ID)
+}
+
+func TestEvents_InvalidParameters(t *testing.T) {
+	ts, _, _ := newEventsTestServer(t)
+
+	for _, query := range []string{"command=nope", "last_event_id=x"} {
+		resp, err := http.Get(ts.URL + "/events?" + query)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func TestEvents_NotServedWithoutBus(t *testing.T) {
+	store := command.NewStore(command.NewMemoryRepository())
+	tc := newTestClient(t, New(store, manager.New(store)))
+
+	resp := tc.Request(http.MethodGet, "/events", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type GroupsAPI struct {
+	store   *command.Store
+	manager *manager.Manager
+}
+
+func NewGroupsAPI(store *command.Store, mgr *manager.Manager) *GroupsAPI {
+	return &GroupsAPI{
+		store:   store,
+		manager: mgr,
+	}
+}
+
+func (api *GroupsAPI) Router() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", api.handleList)
+	r.Route("/{name}", func(r chi.Router) {
+		r.Post("/start", api.handleStart)
+		r.Post("/stop", api.handleStop)
+	})
+	return r
+}
+
+type groupResult struct {
+	ID      uuid.UUID `json:"id"`
+	Name    string    `json:"name"`
+	Changed bool      `json:"changed"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func (api *GroupsAPI) handleList(w http.ResponseWriter, r *http.Request) {
+	commands, err := api.store.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+
+	groups := map[string][]string{}
+	for _, cmd := range commands {
+		for _, tag := range cmd.Tags {
+			groups[tag] = append(groups[tag], cmd.Name)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"groups": groups})
+}
+
+func (api *GroupsAPI) handleStart(w http.ResponseWriter, r *http.Request) {
+	commands, ok := api.groupCommands(w, r)
+	if !ok {
+		return
+	}
+
+	results := api.manager.StartMany(r.Context(), commandIDs(commands))
+	writeJSON(w, http.StatusOK, map[string]any{"results": toGroupResults(commands, results)})
+}
+
+func (api *GroupsAPI) handleStop(w http.ResponseWriter, r *http.Request) {
+	commands, ok := api.groupCommands(w, r)
+	if !ok {
+		return
+	}
+
+	results := api.manager.StopMany(commandIDs(commands))
+	writeJSON(w, http.StatusOK, map[string]any{"results": toGroupResults(commands, results)})
+}
+
+func (api *GroupsAPI) groupCommands(w http.ResponseWriter, r *http.Request) ([]command.Command, bool) {
+	commands, err := api.store.ListByTag(chi.URLParam(r, "name"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal server error")
+		return nil, false
+	}
+	if len(commands) == 0 {
+		writeError(w, http.StatusNotFound, "group not found")
+		return nil, false
+	}
+	return commands, true
+}
+
+func commandIDs(commands []command.Command) []uuid.UUID {
+	ids := make([]uuid.UUID, len(commands))
+	for i, cmd := range commands {
+		ids[i] = cmd.ID
+	}
+	return ids
+}
+
+func toGroupResults(commands []command.Command, results []manager.BulkResult) []groupResult {
+	out := make([]groupResult, 0, len(results))
+	for _, res := range results {
+		gr := groupResult{ID: res.ID, Changed: res.Changed}
+		if i := slices.IndexFunc(commands, func(c command.Command) bool { return c.ID == res.ID }); i >= 0 {
+			gr.Name = commands[i].Name
+		}
+		if res.Err != nil {
+			gr.Error = res.Err.Error()
+		}
+		out = append(out, gr)
+	}
+	return out
+}
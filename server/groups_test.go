@@ -0,0 +1,105 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/client"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createTagged(t *testing.T, tc *testClient, name string, tags ...string) command.Command {
+	t.Helper()
+	resp := tc.Request(http.MethodPost, "/commands", map[string]any{
+		"name":     name,
+		"command":  "sleep 60",
+		"work_dir": "/tmp",
+		"tags":     tags,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var cmd command.Command
+	require.NoError(t, resp.Decode(&cmd))
+	return cmd
+}
+
+func TestListCommands_FilterByTag(t *testing.T) {
+	_, tc := newTestServer(t)
+	api := createTagged(t, tc, "api", "backend")
+	createTagged(t, tc, "web", "frontend")
+
+	resp := tc.Request(http.MethodGet, "/commands?tag=backend", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Commands []command.Command `json:"commands"`
+	}
+	require.NoError(t, resp.Decode(&result))
+	require.Len(t, result.Commands, 1)
+	assert.Equal(t, api.ID, result.Commands[0].ID)
+}
+
+func TestGroups_StartAndStop(t *testing.T) {
+	srv, tc := newTestServer(t)
+	api := createTagged(t, tc, "api", "backend")
+	worker := createTagged(t, tc, "worker", "backend")
+	web := createTagged(t, tc, "web", "frontend")
+
+	resp := tc.Request(http.MethodPost, "/groups/backend/start", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result struct {
+		Results []groupResult `json:"results"`
+	}
+	require.NoError(t, resp.Decode(&result))
+	require.Len(t, result.Results, 2)
+	for _, r := range result.Results {
+		assert.True(t, r.Changed)
+		assert.Empty(t, r.Error)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	status, _ := tc.Status(t.Context(), api.ID)
+	assert.Equal(t, manager.StatusRunning, status.Status)
+	status, _ = tc.Status(t.Context(), worker.ID)
+	assert.Equal(t, manager.StatusRunning, status.Status)
+	_, err := tc.Status(t.Context(), web.ID)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+
+	resp = tc.Request(http.MethodPost, "/groups/backend/stop", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	status, _ = tc.Status(t.Context(), api.ID)
+	assert.Equal(t, manager.StatusStopped, status.Status)
+	status, _ = tc.Status(t.Context(), worker.ID)
+	assert.Equal(t, manager.StatusStopped, status.Status)
+
+	_ = srv.manager.Stop(web.ID)
+}
+
+func TestGroups_UnknownGroup(t *testing.T) {
+	_, tc := newTestServer(t)
+	createTagged(t, tc, "api", "backend")
+
+	resp := tc.Request(http.MethodPost, "/groups/nope/start", nil)
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestCreateCommand_InvalidTag(t *testing.T) {
+	_, tc := newTestServer(t)
+
+	resp := tc.Request(http.MethodPost, "/commands", map[string]any{
+		"name":     "api",
+		"command":  "sleep 60",
+		"work_dir": "/tmp",
+		"tags":     []string{"back end"},
+	})
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/google/uuid"
+)
+
+// livenessTimeout bounds how long /healthz waits for the manager before
+// declaring it wedged.
+const livenessTimeout = 2 * time.Second
+
+type healthAPI struct {
+	store   *command.Store
+	stuckFn func() []uuid.UUID
+	version string
+	started time.Time
+
+	mu    sync.Mutex
+	probe *stuckProbe
+}
+
+// stuckProbe is a call to stuckFn shared by every probe that arrives while
+// it runs, so a wedged manager holds up at most one goroutine.
+type stuckProbe struct {
+	done chan struct{}
+	ids  []uuid.UUID
+}
+
+// stuck reads the stuck instances, or reports false when the manager does
+// not answer in time.
+func (api *healthAPI) stuck() ([]uuid.UUID, bool) {
+	api.mu.Lock()
+	p := api.probe
+	if p == nil {
+		p = &stuckProbe{done: make(chan struct{})}
+		api.probe = p
+		go func() {
+			p.ids = api.stuckFn()
+			api.mu.Lock()
+			api.probe = nil
+			api.mu.Unlock()
+			close(p.done)
+		}()
+	}
+	api.mu.Unlock()
+
+	timer := time.NewTimer(livenessTimeout)
+	defer timer.Stop()
+	select {
+	case <-p.done:
+		return p.ids, true
+	case <-timer.C:
+		return nil, false
+	}
+}
+
+// handleHealthz is the liveness probe: it fails only when the manager is
+// unresponsive, which a restart would fix.
+func (api *healthAPI) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	body := map[string]any{
+		"version": api.version,
+		"uptime":  time.Since(api.started).Round(time.Second).String(),
+	}
+	if _, ok := api.stuck(); !ok {
+		body["status"] = "unresponsive"
+		writeJSON(w, http.StatusServiceUnavailable, body)
+		return
+	}
+	body["status"] = "ok"
+	writeJSON(w, http.StatusOK, body)
+}
+
+// handleReadyz is the readiness probe: it fails while commands could not be
+// loaded, the last write to the repository failed or a stop is stuck.
+func (api *healthAPI) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	repo := api.store.Health()
+	stuck, responsive := api.stuck()
+	if stuck == nil {
+		stuck = []uuid.UUID{}
+	}
+
+	ready := responsive && repo.Loaded && repo.LastWriteError == "" && len(stuck) == 0
+	status, code := "ready", http.StatusOK
+	if !ready {
+		status, code = "not_ready", http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, code, map[string]any{
+		"status":          status,
+		"version":         api.version,
+		"repository":      repo,
+		"stuck_instances": stuck,
+		"responsive":      responsive,
+	})
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/auth"
+	"github.com/cloud-gt/ai-sensors/client"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type readyzResponse struct {
+	Status     string         `json:"status"`
+	Version    string         `json:"version"`
+	Repository command.Health `json:"repository"`
+	Stuck      []string       `json:"stuck_instances"`
+}
+
+type failingRepository struct {
+	command.MemoryRepository
+	err error
+}
+
+func (r *failingRepository) Save(commands []command.Command) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.MemoryRepository.Save(commands)
+}
+
+func TestHealthz(t *testing.T) {
+	store := command.NewStore(command.NewMemoryRepository())
+	tc := newTestClient(t, New(store, manager.New(store), WithVersion("1.2.3")))
+
+	resp := tc.Request(http.MethodGet, "/healthz", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var body map[string]string
+	require.NoError(t, resp.Decode(&body))
+	assert.Equal(t, "ok", body["status"])
+	assert.Equal(t, "1.2.3", body["version"])
+}
+
+func TestReadyz(t *testing.T) {
+	repo := &failingRepository{}
+	store := command.NewStore(repo)
+	tc := newTestClient(t, New(store, manager.New(store)))
+
+	var body readyzResponse
+	resp := tc.Request(http.MethodGet, "/readyz", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "commands not loaded yet")
+	require.NoError(t, resp.Decode(&body))
+	assert.Equal(t, "not_ready", body.Status)
+	assert.False(t, body.Repository.Loaded)
+
+	require.NoError(t, store.Load())
+	resp = tc.Request(http.MethodGet, "/readyz", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, resp.Decode(&body))
+	assert.Equal(t, "ready", body.Status)
+	assert.Equal(t, "dev", body.Version)
+	assert.Empty(t, body.Stuck)
+
+	repo.err = errors.New("read-only file system")
+	_, err := tc.CreateCommand(t.Context(), command.Command{Name: "x", Command: "true", WorkDir: "/tmp"})
+	require.ErrorIs(t, err, client.ErrServer)
+
+	resp = tc.Request(http.MethodGet, "/readyz", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.NoError(t, resp.Decode(&body))
+	assert.Equal(t, uint64(1), body.Repository.WriteFailures)
+	assert.Equal(t, "read-only file system", body.Repository.LastWriteError)
+}
+
+func TestHealth_ConcurrentProbesShareOneCall(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+	id := uuid.New()
+	api := &healthAPI{stuckFn: func() []uuid.UUID {
+		calls.Add(1)
+		<-release
+		return []uuid.UUID{id}
+	}}
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids, ok := api.stuck()
+			assert.True(t, ok)
+			assert.Equal(t, []uuid.UUID{id}, ids)
+		}()
+	}
+	require.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, ok := api.stuck()
+	assert.True(t, ok)
+	assert.Equal(t, int32(2), calls.Load(), "a finished probe is not reused")
+}
+
+func TestProbesArePublic(t *testing.T) {
+	tokens := auth.NewTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+	_, _, err := tokens.Create("ci", auth.AllScopes)
+	require.NoError(t, err)
+
+	store := command.NewStore(command.NewMemoryRepository())
+	require.NoError(t, store.Load())
+	tc := newTestClient(t, New(store, manager.New(store), WithTokens(tokens)))
+
+	assert.Equal(t, http.StatusOK, tc.Request(http.MethodGet, "/healthz", nil).StatusCode)
+	assert.Equal(t, http.StatusOK, tc.Request(http.MethodGet, "/readyz", nil).StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, tc.Request(http.MethodGet, "/commands", nil).StatusCode)
+}
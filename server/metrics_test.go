@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/procstats"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type metricsResponse struct {
+	Latest  *procstats.Snapshot `json:"latest"`
+	Samples []procstats.Sample  `json:"samples"`
+}
+
+func TestGetCommandMetrics(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process metrics need /proc")
+	}
+	store := command.NewStore(command.NewMemoryRepository())
+	tc := newTestClient(t, New(store, manager.New(store, manager.WithSampleInterval(20*time.Millisecond))))
+
+	created, _ := tc.CreateCommand(t.Context(), command.Command{Name: "server", Command: "sleep 30 & sleep 30; wait", WorkDir: "/tmp"})
+	_, err := tc.StartCommand(t.Context(), created.ID)
+	require.NoError(t, err)
+	defer func() { _ = tc.StopCommand(context.Background(), created.ID) }()
+
+	var metrics metricsResponse
+	require.Eventually(t, func() bool {
+		resp := tc.Request(http.MethodGet, "/commands/"+created.ID.String()+"/metrics", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, resp.Decode(&metrics))
+		return metrics.Latest != nil && metrics.Latest.Processes == 3 && len(metrics.Samples) >= 2
+	}, 5*time.Second, 50*time.Millisecond)
+
+	assert.Positive(t, metrics.Latest.RSSBytes)
+	require.Len(t, metrics.Latest.Tree, 1)
+	assert.Len(t, metrics.Latest.Tree[0].Children, 2)
+
+	last := metrics.Samples[len(metrics.Samples)-1].Time
+	resp := tc.Request(http.MethodGet, "/commands/"+created.ID.String()+"/metrics?since="+url.QueryEscape(last.Format(time.RFC3339Nano)), nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var incremental metricsResponse
+	require.NoError(t, resp.Decode(&incremental))
+	for _, s := range incremental.Samples {
+		assert.True(t, s.Time.After(last))
+	}
+}
+
+func TestGetCommandMetrics_Errors(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("process metrics need /proc")
+	}
+	_, tc := newTestServer(t)
+	created, _ := tc.CreateCommand(t.Context(), command.Command{Name: "idle", Command: "sleep 1", WorkDir: "/tmp"})
+
+	resp := tc.Request(http.MethodGet, "/commands/"+created.ID.String()+"/metrics", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp = tc.Request(http.MethodGet, "/commands/"+created.ID.String()+"/metrics?since=yesterday", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPISpec is the OpenAPI 3 description of the REST API. Update it with
+// any change to a route, parameter or response; the tests check it against
+// the router and the handlers.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPISpec)
+}
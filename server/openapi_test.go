@@ -0,0 +1,362 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/auth"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/webhook"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPI checks responses against the embedded document. Objects must not
+// carry properties the document does not list, so a field added to a
+// handler without updating the document fails the tests.
+type openAPI struct {
+	doc map[string]any
+}
+
+func loadOpenAPI(t *testing.T) *openAPI {
+	t.Helper()
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	return &openAPI{doc: doc}
+}
+
+func (o *openAPI) lookup(ref string) map[string]any {
+	var node any = o.doc
+	for _, key := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		node = node.(map[string]any)[key]
+	}
+	return node.(map[string]any)
+}
+
+func (o *openAPI) resolve(node map[string]any) map[string]any {
+	for {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			return node
+		}
+		node = o.lookup(ref)
+	}
+}
+
+// flatten merges the members of an allOf into a single object schema.
+func (o *openAPI) flatten(schema map[string]any) map[string]any {
+	schema = o.resolve(schema)
+	all, ok := schema["allOf"].([]any)
+	if !ok {
+		return schema
+	}
+	merged := map[string]any{"nullable": schema["nullable"]}
+	props := map[string]any{}
+	var required []any
+	for _, member := range all {
+		m := o.flatten(member.(map[string]any))
+		for k, v := range m {
+			switch k {
+			case "properties":
+				for name, p := range v.(map[string]any) {
+					props[name] = p
+				}
+			case "required":
+				required = append(required, v.([]any)...)
+			default:
+				if merged[k] == nil {
+					merged[k] = v
+				}
+			}
+		}
+	}
+	merged["type"] = "object"
+	merged["properties"] = props
+	merged["required"] = required
+	return merged
+}
+
+func (o *openAPI) validate(schema map[string]any, v any, at string) error {
+	schema = o.flatten(schema)
+	if v == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return fmt.Errorf("%s: null is not allowed", at)
+	}
+	if oneOf, ok := schema["oneOf"].([]any); ok {
+		matched := 0
+		for _, s := range oneOf {
+			if o.validate(s.(map[string]any), v, at) == nil {
+				matched++
+			}
+		}
+		if matched != 1 {
+			return fmt.Errorf("%s: matches %d schemas of oneOf", at, matched)
+		}
+		return nil
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, v) {
+		return fmt.Errorf("%s: %v is not one of %v", at, v, enum)
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: want object, got %T", at, v)
+		}
+		required, _ := schema["required"].([]any)
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %q", at, name)
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for name, value := range obj {
+			prop, ok := props[name].(map[string]any)
+			if !ok {
+				prop, ok = schema["additionalProperties"].(map[string]any)
+			}
+			if !ok {
+				return fmt.Errorf("%s: undocumented property %q", at, name)
+			}
+			if err := o.validate(prop, value, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		items, ok := v.([]any)
+		if !ok {
+			return fmt.Errorf("%s: want array, got %T", at, v)
+		}
+		for i, item := range items {
+			if err := o.validate(schema["items"].(map[string]any), item, fmt.Sprintf("%s[%d]", at, i)); err != nil {
+				return err
+			}
+		}
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: want string, got %T", at, v)
+		}
+		switch schema["format"] {
+		case "uuid":
+			if _, err := uuid.Parse(s); err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+		case "date-time":
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				return fmt.Errorf("%s: %w", at, err)
+			}
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: want integer, got %v", at, v)
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return fmt.Errorf("%s: want number, got %T", at, v)
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return fmt.Errorf("%s: want boolean, got %T", at, v)
+		}
+	}
+	return nil
+}
+
+// check asserts that the document lists the status of resp for the route
+// and that the body matches the documented schema.
+func (o *openAPI) check(t *testing.T, method, route string, resp *Response) {
+	t.Helper()
+	name := method + " " + route
+	path, ok := o.doc["paths"].(map[string]any)[route].(map[string]any)
+	require.True(t, ok, "%s: route not documented", name)
+	op, ok := path[strings.ToLower(method)].(map[string]any)
+	require.True(t, ok, "%s: method not documented", name)
+	documented, ok := op["responses"].(map[string]any)[strconv.Itoa(resp.StatusCode)].(map[string]any)
+	require.True(t, ok, "%s: status %d not documented (body %s)", name, resp.StatusCode, resp.Body)
+
+	content, ok := o.resolve(documented)["content"].(map[string]any)
+	if !ok {
+		assert.Empty(t, resp.Body, name)
+		return
+	}
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	require.NoError(t, err, name)
+	media, ok := content[mediaType].(map[string]any)
+	require.True(t, ok, "%s: content type %s not documented", name, mediaType)
+	if mediaType != "application/json" {
+		return
+	}
+	var body any
+	require.NoError(t, json.Unmarshal(resp.Body, &body), name)
+	assert.NoError(t, o.validate(media["schema"].(map[string]any), body, "body"), name)
+}
+
+func newOpenAPITestServer(t *testing.T) (*Server, *testClient) {
+	t.Helper()
+	bus := events.NewBus()
+	store := command.NewStore(command.NewMemoryRepository(), command.WithEvents(bus))
+	mgr := manager.New(store, manager.WithEvents(bus))
+	dispatcher := webhook.New([]webhook.Hook{
+		{Name: "bot", URL: "http://127.0.0.1:0", Secret: "hush", On: []webhook.Trigger{webhook.TriggerCrash}},
+	}, store)
+	srv := New(store, mgr, WithEvents(bus), WithWebhooks(dispatcher))
+	return srv, newTestClient(t, srv)
+}
+
+func TestOpenAPI_Served(t *testing.T) {
+	_, tc := newTestServer(t)
+
+	resp := tc.Request(http.MethodGet, "/openapi.json", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	var doc struct {
+		OpenAPI string         `json:"openapi"`
+		Paths   map[string]any `json:"paths"`
+	}
+	require.NoError(t, resp.Decode(&doc))
+	assert.True(t, strings.HasPrefix(doc.OpenAPI, "3."))
+	assert.NotEmpty(t, doc.Paths)
+}
+
+func TestOpenAPI_PublicWithTokens(t *testing.T) {
+	_, tc, tokens := newAuthTestServer(t)
+	createToken(t, tokens, "reader", auth.ScopeRead)
+
+	assert.Equal(t, http.StatusOK, tc.Request(http.MethodGet, "/openapi.json", nil).StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, tc.Request(http.MethodGet, "/commands", nil).StatusCode)
+}
+
+func TestOpenAPI_DocumentsEveryRoute(t *testing.T) {
+	srv, _ := newOpenAPITestServer(t)
+	spec := loadOpenAPI(t)
+
+	var routed []string
+	err := chi.Walk(srv.router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if route != "/" {
+			route = strings.TrimSuffix(route, "/")
+		}
+		if route != "/openapi.json" {
+			routed = append(routed, method+" "+route)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var documented []string
+	for route, item := range spec.doc["paths"].(map[string]any) {
+		for method := range item.(map[string]any) {
+			documented = append(documented, strings.ToUpper(method)+" "+route)
+		}
+	}
+	assert.ElementsMatch(t, routed, documented)
+}
+
+func TestOpenAPI_MatchesHandlers(t *testing.T) {
+	_, tc := newOpenAPITestServer(t)
+	spec := loadOpenAPI(t)
+
+	check := func(method, route, path string, body any) *Response {
+		t.Helper()
+		resp := tc.Request(method, path, body)
+		spec.check(t, method, route, resp)
+		return resp
+	}
+
+	check(http.MethodGet, "/healthz", "/healthz", nil)
+	check(http.MethodGet, "/readyz", "/readyz", nil)
+	check(http.MethodGet, "/metrics", "/metrics", nil)
+	check(http.MethodGet, "/events", "/events?command=nope", nil)
+	check(http.MethodGet, "/commands", "/commands", nil)
+
+	resp := check(http.MethodPost, "/commands", "/commands", map[string]any{
+		"name":      "build",
+		"command":   `printf '1..2\nok 1 - adds\nnot ok 2 - divides\n./main.go:10:2: undefined: foo\n'; echo "token=$TOKEN"`,
+		"work_dir":  "/tmp",
+		"tags":      []string{"ci"},
+		"tests":     map[string]any{"format": "tap"},
+		"matchers":  []map[string]string{{"name": "go"}},
+		"env":       []map[string]any{{"name": "TOKEN", "value": "s3cr3t", "secret": true}},
+		"readiness": map[string]any{"checks": []map[string]string{{"type": "log", "pattern": "adds"}}, "timeout": "5s"},
+		"limits":    map[string]any{"timeout": "1m", "open_files": 256},
+		"normalize": map[string]any{"disable": []string{"timestamps"}},
+		"redact":    map[string]any{"patterns": []string{"s3cr3t"}},
+		"watch":     map[string]any{"include": []string{"*.go"}, "debounce": "100ms"},
+	})
+	var build command.Command
+	require.NoError(t, resp.Decode(&build))
+	buildPath := "/commands/" + build.ID.String()
+
+	check(http.MethodPost, "/commands", "/commands", map[string]any{"name": "build"})
+	check(http.MethodPost, "/commands", "/commands", map[string]any{"name": "build", "command": "true", "work_dir": "/tmp"})
+	check(http.MethodGet, "/commands", "/commands?tag=ci", nil)
+	check(http.MethodGet, "/commands/{id}", buildPath, nil)
+	check(http.MethodGet, "/commands/{id}", "/commands/nope", nil)
+	check(http.MethodGet, "/commands/{id}", "/commands/"+uuid.NewString(), nil)
+	check(http.MethodGet, "/commands/{id}/status", buildPath+"/status", nil)
+	check(http.MethodGet, "/commands/{id}/output", buildPath+"/output", nil)
+
+	for range 2 {
+		resp = check(http.MethodPost, "/commands/{id}/run", buildPath+"/run?wait=true&lines=10", nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	check(http.MethodPost, "/commands/{id}/run", buildPath+"/run?timeout=never", nil)
+	check(http.MethodGet, "/commands/{id}/status", buildPath+"/status", nil)
+	check(http.MethodGet, "/commands/{id}/output", buildPath+"/output", nil)
+	check(http.MethodGet, "/commands/{id}/output", buildPath+"/output?lines=2", nil)
+	check(http.MethodGet, "/commands/{id}/output", buildPath+"/output?entries=true", nil)
+	check(http.MethodGet, "/commands/{id}/output", buildPath+"/output?lines=x", nil)
+	check(http.MethodGet, "/commands/{id}/output/stream", buildPath+"/output/stream?lines=2", nil)
+	check(http.MethodGet, "/commands/{id}/digest", buildPath+"/digest?max_chars=40", nil)
+	check(http.MethodGet, "/commands/{id}/digest", buildPath+"/digest?run=1", nil)
+	check(http.MethodGet, "/commands/{id}/digest", buildPath+"/digest?run=9", nil)
+	check(http.MethodGet, "/commands/{id}/runs", buildPath+"/runs", nil)
+	check(http.MethodGet, "/commands/{id}/runs/diff", buildPath+"/runs/diff", nil)
+	check(http.MethodGet, "/commands/{id}/runs/diff", buildPath+"/runs/diff?from=0", nil)
+	check(http.MethodGet, "/commands/{id}/tests", buildPath+"/tests", nil)
+	check(http.MethodGet, "/commands/{id}/tests", buildPath+"/tests?failed=true", nil)
+	check(http.MethodGet, "/commands/{id}/diagnostics", buildPath+"/diagnostics", nil)
+	check(http.MethodGet, "/commands/{id}/diagnostics", buildPath+"/diagnostics?severity=fatal", nil)
+	check(http.MethodGet, "/commands/{id}/metrics", buildPath+"/metrics?since=yesterday", nil)
+
+	resp = check(http.MethodPost, "/commands", "/commands", map[string]any{
+		"name": "server", "command": "sleep 60", "work_dir": "/tmp", "tags": []string{"web"},
+	})
+	var server command.Command
+	require.NoError(t, resp.Decode(&server))
+	serverPath := "/commands/" + server.ID.String()
+
+	check(http.MethodPost, "/commands/{id}/stop", serverPath+"/stop", nil)
+	check(http.MethodPost, "/commands/{id}/run", serverPath+"/run", nil)
+	check(http.MethodPost, "/commands/{id}/run", serverPath+"/run", nil)
+	check(http.MethodPost, "/commands/{id}/start", serverPath+"/start", nil)
+	check(http.MethodGet, "/commands/{id}/metrics", serverPath+"/metrics", nil)
+	check(http.MethodDelete, "/commands/{id}", serverPath, nil)
+	check(http.MethodPost, "/commands/{id}/stop", serverPath+"/stop", nil)
+	check(http.MethodPost, "/commands/{id}/start", "/commands/"+uuid.NewString()+"/start", nil)
+
+	check(http.MethodGet, "/groups", "/groups", nil)
+	check(http.MethodPost, "/groups/{name}/start", "/groups/web/start", nil)
+	check(http.MethodPost, "/groups/{name}/stop", "/groups/web/stop", nil)
+	check(http.MethodPost, "/groups/{name}/start", "/groups/none/start", nil)
+	check(http.MethodDelete, "/commands/{id}", serverPath, nil)
+
+	check(http.MethodGet, "/webhooks", "/webhooks", nil)
+	check(http.MethodGet, "/webhooks/deliveries", "/webhooks/deliveries", nil)
+	check(http.MethodGet, "/webhooks/deliveries", "/webhooks/deliveries?status=lost", nil)
+}
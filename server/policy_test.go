@@ -0,0 +1,67 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/client"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/policy"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPolicyTestServer(t *testing.T, rules policy.Rules, preload ...command.Command) *testClient {
+	t.Helper()
+	p, err := policy.New(rules)
+	require.NoError(t, err)
+
+	repo := command.NewMemoryRepository()
+	require.NoError(t, repo.Save(preload))
+	store := command.NewStore(repo, command.WithPolicy(p))
+	require.NoError(t, store.Load())
+	return newTestClient(t, New(store, manager.New(store, manager.WithPolicy(p))))
+}
+
+func TestCreateCommand_DeniedByPolicy(t *testing.T) {
+	tc := newPolicyTestServer(t, policy.Rules{Executables: []string{"go"}, WorkDirRoots: []string{"/tmp"}})
+
+	_, err := tc.CreateCommand(t.Context(), command.Command{Name: "ok", Command: "go test ./...", WorkDir: "/tmp"})
+	assert.NoError(t, err)
+
+	_, err = tc.CreateCommand(t.Context(), command.Command{Name: "curl", Command: "curl example.com", WorkDir: "/tmp"})
+	assert.ErrorIs(t, err, client.ErrForbidden)
+
+	_, err = tc.CreateCommand(t.Context(), command.Command{Name: "elsewhere", Command: "go test ./...", WorkDir: "/etc"})
+	assert.ErrorIs(t, err, client.ErrForbidden)
+}
+
+func TestStartCommand_DeniedByPolicy(t *testing.T) {
+	legacy := command.Command{ID: uuid.New(), Name: "legacy", Command: "curl example.com", WorkDir: "/tmp"}
+	tc := newPolicyTestServer(t, policy.Rules{Executables: []string{"go"}}, legacy)
+
+	_, err := tc.StartCommand(t.Context(), legacy.ID)
+	assert.ErrorIs(t, err, client.ErrForbidden)
+}
+
+func TestReadOnlyPolicy(t *testing.T) {
+	fixed := command.Command{ID: uuid.New(), Name: "fixed", Command: "echo hi", WorkDir: "/tmp"}
+	tc := newPolicyTestServer(t, policy.Rules{ReadOnly: true}, fixed)
+
+	commands, err := tc.ListCommands(t.Context())
+	require.NoError(t, err)
+	require.Len(t, commands, 1)
+
+	_, err = tc.CreateCommand(t.Context(), command.Command{Name: "new", Command: "echo new", WorkDir: "/tmp"})
+	assert.ErrorIs(t, err, client.ErrForbidden)
+
+	err = tc.DeleteCommand(t.Context(), fixed.ID)
+	assert.ErrorIs(t, err, client.ErrForbidden)
+
+	started, err := tc.StartCommand(t.Context(), fixed.ID)
+	assert.NoError(t, err)
+	assert.True(t, started)
+	err = tc.StopCommand(t.Context(), fixed.ID)
+	require.NoError(t, err)
+}
@@ -0,0 +1,109 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/metrics"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/google/uuid"
+)
+
+var allStatuses = []manager.Status{
+	manager.StatusNotStarted,
+	manager.StatusStarting,
+	manager.StatusRunning,
+	manager.StatusReady,
+	manager.StatusUnhealthy,
+	manager.StatusStopped,
+}
+
+// newMetrics registers the server's Prometheus metrics. Manager and buffer
+// figures are read on every scrape; request latencies are observed by the
+// returned middleware.
+func newMetrics(store *command.Store, mgr *manager.Manager) (*metrics.Registry, func(http.Handler) http.Handler) {
+	reg := metrics.NewRegistry()
+
+	reg.GaugeFunc("ai_sensors_commands_defined", "Number of command definitions.", nil, func() []metrics.Sample {
+		commands, _ := store.List()
+		return []metrics.Sample{{Value: float64(len(commands))}}
+	})
+
+	reg.GaugeFunc("ai_sensors_instances", "Commands by current status.", []string{"status"}, func() []metrics.Sample {
+		commands, _ := store.List()
+		stats := mgr.Stats()
+		counts := make(map[manager.Status]int, len(allStatuses))
+		for _, cmd := range commands {
+			status := stats[cmd.ID].Status
+			if status == "" {
+				status = manager.StatusNotStarted
+			}
+			counts[status]++
+		}
+		samples := make([]metrics.Sample, len(allStatuses))
+		for i, s := range allStatuses {
+			samples[i] = metrics.Sample{Labels: []string{string(s)}, Value: float64(counts[s])}
+		}
+		return samples
+	})
+
+	perCommand := func(value func(manager.CommandStats) float64) func() []metrics.Sample {
+		return func() []metrics.Sample {
+			commands, _ := store.List()
+			names := make(map[uuid.UUID]string, len(commands))
+			for _, cmd := range commands {
+				names[cmd.ID] = cmd.Name
+			}
+			var samples []metrics.Sample
+			for id, s := range mgr.Stats() {
+				name, ok := names[id]
+				if !ok {
+					continue // deleted since it last ran
+				}
+				samples = append(samples, metrics.Sample{Labels: []string{id.String(), name}, Value: value(s)})
+			}
+			return samples
+		}
+	}
+	labels := []string{"command_id", "command"}
+
+	reg.CounterFunc("ai_sensors_starts_total", "Runs started.", labels,
+		perCommand(func(s manager.CommandStats) float64 { return float64(s.Starts) }))
+	reg.CounterFunc("ai_sensors_stops_total", "Runs that were stopped or exited successfully.", labels,
+		perCommand(func(s manager.CommandStats) float64 { return float64(s.Stops) }))
+	reg.CounterFunc("ai_sensors_crashes_total", "Runs that failed with a non-zero exit, a signal or a resource limit.", labels,
+		perCommand(func(s manager.CommandStats) float64 { return float64(s.Crashes) }))
+	reg.CounterFunc("ai_sensors_output_lines_total", "Output lines ingested.", labels,
+		perCommand(func(s manager.CommandStats) float64 { return float64(s.Output.Lines) }))
+	reg.CounterFunc("ai_sensors_output_dropped_lines_total", "Output lines evicted from full buffers.", labels,
+		perCommand(func(s manager.CommandStats) float64 { return float64(s.Output.Dropped) }))
+	reg.GaugeFunc("ai_sensors_output_buffered_bytes", "Bytes of output held in buffers.", labels,
+		perCommand(func(s manager.CommandStats) float64 { return float64(s.Output.Bytes) }))
+
+	latency := reg.Histogram("ai_sensors_http_request_duration_seconds", "HTTP request latencies by route.",
+		metrics.DefaultBuckets, "method", "route", "code")
+
+	observe := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			route := "unmatched"
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+			latency.Observe(time.Since(start).Seconds(), r.Method, route, strconv.Itoa(code))
+		})
+	}
+
+	return reg, observe
+}
@@ -0,0 +1,54 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	_, tc := newTestServer(t)
+
+	crasher, _ := tc.CreateCommand(t.Context(), command.Command{Name: "crasher", Command: "printf 'a\\nb\\nc\\n'; exit 3", WorkDir: "/tmp"})
+	server, _ := tc.CreateCommand(t.Context(), command.Command{Name: "server", Command: "sleep 60", WorkDir: "/tmp"})
+	tc.CreateCommand(t.Context(), command.Command{Name: "idle", Command: "true", WorkDir: "/tmp"})
+
+	_, err := tc.StartCommand(t.Context(), crasher.ID)
+	require.NoError(t, err)
+	_, err = tc.StartCommand(t.Context(), server.ID)
+	require.NoError(t, err)
+	defer func() { _ = tc.StopCommand(context.Background(), server.ID) }()
+	require.Eventually(t, func() bool {
+		status, _ := tc.Status(t.Context(), crasher.ID)
+		return status.Status == manager.StatusStopped
+	}, 5*time.Second, 20*time.Millisecond)
+	tc.Output(t.Context(), server.ID)
+
+	resp := tc.Request(http.MethodGet, "/metrics", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body := string(resp.Body)
+
+	assert.Contains(t, body, "ai_sensors_commands_defined 3\n")
+	assert.Contains(t, body, `ai_sensors_instances{status="running"} 1`+"\n")
+	assert.Contains(t, body, `ai_sensors_instances{status="stopped"} 1`+"\n")
+	assert.Contains(t, body, `ai_sensors_instances{status="not_started"} 1`+"\n")
+
+	crasherLabels := fmt.Sprintf(`{command_id="%s",command="crasher"}`, crasher.ID)
+	assert.Contains(t, body, "ai_sensors_starts_total"+crasherLabels+" 1\n")
+	assert.Contains(t, body, "ai_sensors_crashes_total"+crasherLabels+" 1\n")
+	assert.Contains(t, body, "ai_sensors_stops_total"+crasherLabels+" 0\n")
+	assert.Contains(t, body, "ai_sensors_output_lines_total"+crasherLabels+" 3\n")
+	assert.Contains(t, body, "ai_sensors_output_buffered_bytes"+crasherLabels+" 3\n")
+	assert.Contains(t, body, "ai_sensors_output_dropped_lines_total"+crasherLabels+" 0\n")
+
+	assert.Contains(t, body, `ai_sensors_http_request_duration_seconds_count{method="POST",route="/commands/{id}/start",code="200"} 2`+"\n")
+	assert.Contains(t, body, `ai_sensors_http_request_duration_seconds_count{method="POST",route="/commands",code="201"} 3`+"\n")
+	assert.Contains(t, body, `route="/commands/{id}/output"`)
+}
@@ -3,30 +3,102 @@ package server
 import (
 	"context"
 	"io/fs"
+	"net"
 	"net/http"
+	"time"
 
+	"github.com/cloud-gt/ai-sensors/auth"
 	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/endpoint"
+	"github.com/cloud-gt/ai-sensors/events"
 	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/webhook"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 type Server struct {
-	router  chi.Router
-	server  *http.Server
-	manager *manager.Manager
+	router     chi.Router
+	server     *http.Server
+	manager    *manager.Manager
+	tokens     *auth.TokenStore
+	socketMode fs.FileMode
+	version    string
+	events     *events.Bus
+	webhooks   *webhook.Dispatcher
 }
 
-func New(store *command.Store, mgr *manager.Manager) *Server {
+type Option func(*Server)
+
+// WithTokens requires API requests to carry a bearer token from tokens once
+// it holds at least one. The dashboard assets stay public; the dashboard
+// itself asks for a token.
+func WithTokens(tokens *auth.TokenStore) Option {
+	return func(s *Server) {
+		s.tokens = tokens
+	}
+}
+
+// WithSocketMode sets the permissions of a Unix socket created by
+// ListenAndServe. The default allows only the owner to connect.
+func WithSocketMode(mode fs.FileMode) Option {
+	return func(s *Server) {
+		s.socketMode = mode
+	}
+}
+
+// WithVersion sets the build version reported by /healthz and /readyz.
+func WithVersion(version string) Option {
+	return func(s *Server) {
+		s.version = version
+	}
+}
+
+func New(store *command.Store, mgr *manager.Manager, opts ...Option) *Server {
 	s := &Server{
-		router:  chi.NewRouter(),
-		manager: mgr,
+		router:     chi.NewRouter(),
+		manager:    mgr,
+		socketMode: endpoint.DefaultSocketMode,
+		version:    "dev",
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	s.server = &http.Server{Handler: s.router}
 
+	registry, observe := newMetrics(store, mgr)
+	s.router.Use(queryToken)
 	s.router.Use(middleware.Logger)
+	s.router.Use(observe)
 
-	commandsAPI := NewCommandsAPI(store, mgr)
-	s.router.Mount("/commands", commandsAPI.Router())
+	// Probes stay public so supervisors need no token, and so does the API
+	// description so clients can be generated from it.
+	health := &healthAPI{store: store, stuckFn: mgr.Stuck, version: s.version, started: time.Now()}
+	s.router.Get("/healthz", health.handleHealthz)
+	s.router.Get("/readyz", health.handleReadyz)
+	s.router.Get("/openapi.json", handleOpenAPI)
+
+	s.router.Group(func(r chi.Router) {
+		r.Use(requireToken(s.tokens))
+		r.Use(requireJSON)
+
+		r.Method(http.MethodGet, "/metrics", registry.Handler())
+
+		if s.events != nil {
+			eventsAPI := &eventsAPI{bus: s.events, heartbeat: eventsHeartbeat}
+			r.Get("/events", eventsAPI.handleEvents)
+		}
+
+		commandsAPI := NewCommandsAPI(store, mgr)
+		r.Mount("/commands", commandsAPI.Router())
+
+		groupsAPI := NewGroupsAPI(store, mgr)
+		r.Mount("/groups", groupsAPI.Router())
+
+		if s.webhooks != nil {
+			r.Mount("/webhooks", NewWebhooksAPI(s.webhooks).Router())
+		}
+	})
 
 	return s
 }
@@ -44,20 +116,23 @@ func (s *Server) Router() chi.Router {
 	return s.router
 }
 
+// ListenAndServe serves on addr, either host:port or unix:///path/to.sock.
 func (s *Server) ListenAndServe(addr string) error {
-	s.server = &http.Server{
-		Addr:    addr,
-		Handler: s.router,
+	l, err := endpoint.Listen(addr, s.socketMode)
+	if err != nil {
+		return err
 	}
-	return s.server.ListenAndServe()
+	return s.Serve(l)
+}
+
+// Serve serves on an existing listener, which is closed on Shutdown.
+func (s *Server) Serve(l net.Listener) error {
+	return s.server.Serve(l)
 }
 
 func (s *Server) Shutdown(ctx context.Context) error {
 	if err := s.manager.Shutdown(ctx); err != nil {
 		return err
 	}
-	if s.server != nil {
-		return s.server.Shutdown(ctx)
-	}
-	return nil
+	return s.server.Shutdown(ctx)
 }
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/auth"
+	"github.com/cloud-gt/ai-sensors/client"
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_OverUnixSocket(t *testing.T) {
+	srv, _ := newTestServer(t)
+	tc, path := newSocketTestClient(t, srv)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	created, err := tc.CreateCommand(t.Context(), command.Command{Name: "echo", Command: "echo over the socket", WorkDir: "/tmp"})
+	require.NoError(t, err)
+
+	result, err := tc.Run(t.Context(), created.ID, client.RunOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, result.ExitCode)
+	assert.Equal(t, 0, *result.ExitCode)
+
+	lines, err := tc.Output(t.Context(), created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"over the socket"}, lines)
+}
+
+func TestServer_UnixSocketWithAuth(t *testing.T) {
+	tokens := auth.NewTokenStore(t.TempDir() + "/tokens.json")
+	_, secret, err := tokens.Create("agent", []auth.Scope{auth.ScopeRead})
+	require.NoError(t, err)
+
+	store := command.NewStore(command.NewMemoryRepository())
+	srv := New(store, manager.New(store), WithTokens(tokens))
+	tc, _ := newSocketTestClient(t, srv)
+
+	_, err = tc.ListCommands(t.Context())
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+
+	_, err = tc.WithToken(secret).ListCommands(t.Context())
+	assert.NoError(t, err)
+}
+
+func TestServer_ListenAndServeUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ais")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server.sock")
+
+	store := command.NewStore(command.NewMemoryRepository())
+	srv := New(store, manager.New(store), WithSocketMode(0o660))
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe("unix://" + path) }()
+
+	require.Eventually(t, func() bool {
+		info, err := os.Stat(path)
+		return err == nil && info.Mode().Perm() == 0o660
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, srv.Shutdown(context.Background()))
+	assert.ErrorIs(t, <-errc, http.ErrServerClosed)
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist, "the socket is removed on shutdown")
+}
@@ -2,25 +2,70 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
-	"strconv"
+	"os"
+	"path/filepath"
+	"testing"
 
-	"github.com/cloud-gt/ai-sensors/command"
-	"github.com/google/uuid"
+	"github.com/cloud-gt/ai-sensors/client"
+	"github.com/cloud-gt/ai-sensors/endpoint"
+	"github.com/stretchr/testify/require"
 )
 
-type TestClient struct {
-	srv *Server
+// testClient drives a server over a real listener. Typed calls go through
+// the public client; Request sends raw requests for tests asserting on
+// status codes and bodies.
+type testClient struct {
+	*client.Client
+	t     *testing.T
+	addr  string
+	token string
 }
 
-func newTestClient(srv *Server) *TestClient {
-	return &TestClient{srv: srv}
+// newTestClient serves srv over HTTP for the duration of the test.
+func newTestClient(t *testing.T, srv *Server) *testClient {
+	t.Helper()
+	ts := httptest.NewServer(srv.router)
+	t.Cleanup(ts.Close)
+	return dialTestClient(t, ts.URL, "")
+}
+
+// newSocketTestClient serves srv on a Unix socket for the duration of the
+// test and returns a client talking to it over the socket.
+func newSocketTestClient(t *testing.T, srv *Server) (*testClient, string) {
+	t.Helper()
+	dir, err := os.MkdirTemp("", "ais")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	addr := "unix://" + filepath.Join(dir, "server.sock")
+
+	l, err := endpoint.Listen(addr, endpoint.DefaultSocketMode)
+	require.NoError(t, err)
+	go func() { _ = srv.Serve(l) }()
+	t.Cleanup(func() { _ = srv.Shutdown(context.Background()) })
+
+	return dialTestClient(t, addr, ""), filepath.Join(dir, "server.sock")
+}
+
+func dialTestClient(t *testing.T, addr, token string) *testClient {
+	t.Helper()
+	c, err := client.New(addr, client.WithToken(token))
+	require.NoError(t, err)
+	return &testClient{Client: c, t: t, addr: addr, token: token}
+}
+
+// WithToken returns a client sending token as a bearer token.
+func (tc *testClient) WithToken(token string) *testClient {
+	return dialTestClient(tc.t, tc.addr, token)
 }
 
 type Response struct {
 	StatusCode int
+	Header     http.Header
 	Body       []byte
 }
 
@@ -28,131 +73,32 @@ func (r *Response) Decode(v any) error {
 	return json.Unmarshal(r.Body, v)
 }
 
-func (tc *TestClient) Do(method, path string, body any) *Response {
-	var reqBody *bytes.Buffer
+// Request sends body, if non-nil, as JSON and returns the response
+// whatever its status.
+func (tc *testClient) Request(method, path string, body any) *Response {
+	tc.t.Helper()
+	var reqBody io.Reader
 	if body != nil {
-		data, _ := json.Marshal(body)
-		reqBody = bytes.NewBuffer(data)
-	} else {
-		reqBody = &bytes.Buffer{}
+		data, err := json.Marshal(body)
+		require.NoError(tc.t, err)
+		reqBody = bytes.NewReader(data)
 	}
 
-	req := httptest.NewRequest(method, path, reqBody)
-	if body != nil {
+	httpClient, baseURL, err := endpoint.HTTPClient(tc.addr)
+	require.NoError(tc.t, err)
+	req, err := http.NewRequest(method, baseURL+path, reqBody)
+	require.NoError(tc.t, err)
+	if body != nil || method == http.MethodPost {
 		req.Header.Set("Content-Type", "application/json")
 	}
-
-	w := httptest.NewRecorder()
-	tc.srv.router.ServeHTTP(w, req)
-
-	return &Response{
-		StatusCode: w.Code,
-		Body:       w.Body.Bytes(),
-	}
-}
-
-func (tc *TestClient) CreateCommand(name, cmd, workDir string) (*command.Command, *Response) {
-	resp := tc.Do(http.MethodPost, "/commands", map[string]string{
-		"name":     name,
-		"command":  cmd,
-		"work_dir": workDir,
-	})
-
-	if resp.StatusCode != http.StatusCreated {
-		return nil, resp
-	}
-
-	var created command.Command
-	_ = resp.Decode(&created)
-	return &created, resp
-}
-
-func (tc *TestClient) GetCommand(id uuid.UUID) (*command.Command, *Response) {
-	resp := tc.Do(http.MethodGet, "/commands/"+id.String(), nil)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, resp
-	}
-
-	var cmd command.Command
-	_ = resp.Decode(&cmd)
-	return &cmd, resp
-}
-
-func (tc *TestClient) ListCommands() ([]command.Command, *Response) {
-	resp := tc.Do(http.MethodGet, "/commands", nil)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, resp
-	}
-
-	var result struct {
-		Commands []command.Command `json:"commands"`
-	}
-	_ = resp.Decode(&result)
-	return result.Commands, resp
-}
-
-func (tc *TestClient) DeleteCommand(id uuid.UUID) *Response {
-	return tc.Do(http.MethodDelete, "/commands/"+id.String(), nil)
-}
-
-func (tc *TestClient) StartCommand(id uuid.UUID) (bool, *Response) {
-	resp := tc.Do(http.MethodPost, "/commands/"+id.String()+"/start", nil)
-
-	if resp.StatusCode != http.StatusOK {
-		return false, resp
-	}
-
-	var result struct {
-		Started bool `json:"started"`
-	}
-	_ = resp.Decode(&result)
-	return result.Started, resp
-}
-
-func (tc *TestClient) StopCommand(id uuid.UUID) *Response {
-	return tc.Do(http.MethodPost, "/commands/"+id.String()+"/stop", nil)
-}
-
-func (tc *TestClient) GetStatus(id uuid.UUID) (string, *Response) {
-	resp := tc.Do(http.MethodGet, "/commands/"+id.String()+"/status", nil)
-
-	if resp.StatusCode != http.StatusOK {
-		return "", resp
+	if tc.token != "" {
+		req.Header.Set("Authorization", "Bearer "+tc.token)
 	}
 
-	var result struct {
-		Status string `json:"status"`
-	}
-	_ = resp.Decode(&result)
-	return result.Status, resp
-}
-
-func (tc *TestClient) GetOutput(id uuid.UUID) ([]string, *Response) {
-	resp := tc.Do(http.MethodGet, "/commands/"+id.String()+"/output", nil)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, resp
-	}
-
-	var result struct {
-		Lines []string `json:"lines"`
-	}
-	_ = resp.Decode(&result)
-	return result.Lines, resp
-}
-
-func (tc *TestClient) GetOutputLastN(id uuid.UUID, n int) ([]string, *Response) {
-	resp := tc.Do(http.MethodGet, "/commands/"+id.String()+"/output?lines="+strconv.Itoa(n), nil)
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, resp
-	}
-
-	var result struct {
-		Lines []string `json:"lines"`
-	}
-	_ = resp.Decode(&result)
-	return result.Lines, resp
+	resp, err := httpClient.Do(req)
+	require.NoError(tc.t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(tc.t, err)
+	return &Response{StatusCode: resp.StatusCode, Header: resp.Header, Body: data}
 }
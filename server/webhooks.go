@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/cloud-gt/ai-sensors/webhook"
+	"github.com/go-chi/chi/v5"
+)
+
+// WithWebhooks serves the hooks and delivery log of d under /webhooks.
+func WithWebhooks(d *webhook.Dispatcher) Option {
+	return func(s *Server) {
+		s.webhooks = d
+	}
+}
+
+type WebhooksAPI struct {
+	dispatcher *webhook.Dispatcher
+}
+
+func NewWebhooksAPI(d *webhook.Dispatcher) *WebhooksAPI {
+	return &WebhooksAPI{dispatcher: d}
+}
+
+func (api *WebhooksAPI) Router() chi.Router {
+	r := chi.NewRouter()
+	r.Get("/", api.handleList)
+	r.Get("/deliveries", api.handleDeliveries)
+	return r
+}
+
+func (api *WebhooksAPI) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"webhooks": api.dispatcher.Hooks()})
+}
+
+// handleDeliveries returns the delivery log, newest first, optionally
+// filtered by the hook and status query parameters.
+func (api *WebhooksAPI) handleDeliveries(w http.ResponseWriter, r *http.Request) {
+	hook := r.URL.Query().Get("hook")
+	status := webhook.DeliveryStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", webhook.DeliveryPending, webhook.DeliveryDelivered, webhook.DeliveryFailed:
+	default:
+		writeError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+
+	deliveries := []webhook.Delivery{}
+	for _, d := range api.dispatcher.Deliveries() {
+		if (hook == "" || d.Hook == hook) && (status == "" || d.Status == status) {
+			deliveries = append(deliveries, d)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deliveries": deliveries})
+}
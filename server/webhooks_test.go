@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks_DeliveryLog(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	bus := events.NewBus()
+	store := command.NewStore(command.NewMemoryRepository(), command.WithEvents(bus))
+	mgr := manager.New(store, manager.WithEvents(bus))
+	dispatcher := webhook.New([]webhook.Hook{
+		{Name: "bot", URL: receiver.URL, Secret: "hush", On: []webhook.Trigger{webhook.TriggerCrash}},
+	}, store)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx, bus)
+	require.Eventually(t, func() bool { return bus.Subscribers() == 1 }, time.Second, time.Millisecond)
+
+	tc := newTestClient(t, New(store, mgr, WithWebhooks(dispatcher)))
+
+	var hooks struct {
+		Webhooks []webhook.Hook `json:"webhooks"`
+	}
+	resp := tc.Request(http.MethodGet, "/webhooks", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, resp.Decode(&hooks))
+	require.Len(t, hooks.Webhooks, 1)
+	assert.Equal(t, "bot", hooks.Webhooks[0].Name)
+	assert.Empty(t, hooks.Webhooks[0].Secret)
+	assert.Equal(t, webhook.MaskURL(receiver.URL), hooks.Webhooks[0].URL)
+
+	cmd, err := store.Create(command.Command{Name: "crashy", Command: "exit 1", WorkDir: "/tmp"})
+	require.NoError(t, err)
+	_, err = mgr.Start(context.Background(), cmd.ID)
+	require.NoError(t, err)
+
+	var log struct {
+		Deliveries []webhook.Delivery `json:"deliveries"`
+	}
+	require.Eventually(t, func() bool {
+		resp := tc.Request(http.MethodGet, "/webhooks/deliveries?status=delivered", nil)
+		return resp.StatusCode == http.StatusOK && resp.Decode(&log) == nil && len(log.Deliveries) == 1
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Equal(t, cmd.ID, log.Deliveries[0].CommandID)
+	assert.Equal(t, webhook.TriggerCrash, log.Deliveries[0].Trigger)
+
+	resp = tc.Request(http.MethodGet, "/webhooks/deliveries?hook=other", nil)
+	require.NoError(t, resp.Decode(&log))
+	assert.Empty(t, log.Deliveries)
+
+	resp = tc.Request(http.MethodGet, "/webhooks/deliveries?status=bogus", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestWebhooks_NotServedWithoutDispatcher(t *testing.T) {
+	store := command.NewStore(command.NewMemoryRepository())
+	tc := newTestClient(t, New(store, manager.New(store)))
+
+	resp := tc.Request(http.MethodGet, "/webhooks/deliveries", nil)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
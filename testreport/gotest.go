@@ -0,0 +1,136 @@
+package testreport
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const FormatGo = "go"
+
+var (
+	goRunRe      = regexp.MustCompile(`^=== (?:RUN|CONT|NAME)\s+(\S+)`)
+	goResultRe   = regexp.MustCompile(`^\s*--- (PASS|FAIL|SKIP): (\S+) \(([\d.]+)s\)`)
+	goPkgOkRe    = regexp.MustCompile(`^ok\s+(\S+)\s+(?:([\d.]+)s|\(cached\))`)
+	goPkgFailRe  = regexp.MustCompile(`^FAIL\s+(\S+)\s+(?:([\d.]+)s|\[.*\])`)
+	goPkgNoTest  = regexp.MustCompile(`^\?\s+(\S+)\s+\[no test files\]`)
+	goBareStatus = regexp.MustCompile(`^(PASS|FAIL)$`)
+)
+
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Elapsed float64 `json:"Elapsed"`
+	Output  string  `json:"Output"`
+}
+
+// GoTestParser builds a report from `go test -json` events or from the
+// plain `go test -v` output, detected line by line.
+type GoTestParser struct {
+	b *builder
+
+	// Plain output names the package only in its trailing ok/FAIL line, so
+	// tests are collected under an unnamed package until then.
+	current string
+}
+
+func NewGoTestParser() *GoTestParser {
+	return &GoTestParser{b: newBuilder(FormatGo)}
+}
+
+func (p *GoTestParser) Feed(line string) {
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+
+	if strings.HasPrefix(line, "{") {
+		var ev goTestEvent
+		if err := json.Unmarshal([]byte(line), &ev); err == nil && ev.Action != "" {
+			p.feedEvent(ev)
+			return
+		}
+	}
+	p.feedPlain(line)
+}
+
+func (p *GoTestParser) Report() Report {
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+	return p.b.report()
+}
+
+func (p *GoTestParser) feedEvent(ev goTestEvent) {
+	if ev.Test == "" {
+		pkg := p.b.pkg(ev.Package)
+		switch ev.Action {
+		case "pass":
+			pkg.Status, pkg.Elapsed = StatusPass, ev.Elapsed
+		case "fail":
+			pkg.Status, pkg.Elapsed = StatusFail, ev.Elapsed
+		case "skip":
+			pkg.Status, pkg.Elapsed = StatusSkip, ev.Elapsed
+		}
+		return
+	}
+
+	tc := p.b.test(ev.Package, ev.Test)
+	switch ev.Action {
+	case "output":
+		out := strings.TrimSuffix(ev.Output, "\n")
+		if goRunRe.MatchString(out) || goResultRe.MatchString(out) {
+			return
+		}
+		p.b.output(tc, out)
+	case "pass":
+		p.b.finish(tc, StatusPass, ev.Elapsed)
+	case "fail":
+		p.b.finish(tc, StatusFail, ev.Elapsed)
+	case "skip":
+		p.b.finish(tc, StatusSkip, ev.Elapsed)
+	}
+}
+
+func (p *GoTestParser) feedPlain(line string) {
+	if m := goRunRe.FindStringSubmatch(line); m != nil {
+		p.b.test("", m[1])
+		p.current = m[1]
+		return
+	}
+
+	if m := goResultRe.FindStringSubmatch(line); m != nil {
+		tc := p.b.test("", m[2])
+		elapsed, _ := strconv.ParseFloat(m[3], 64)
+		status := map[string]Status{"PASS": StatusPass, "FAIL": StatusFail, "SKIP": StatusSkip}[m[1]]
+		p.b.finish(tc, status, elapsed)
+		p.current = ""
+		if status == StatusFail {
+			p.current = m[2]
+		}
+		return
+	}
+
+	if m := goPkgOkRe.FindStringSubmatch(line); m != nil {
+		p.finishPackage(m[1], StatusPass, m[2])
+		return
+	}
+	if m := goPkgFailRe.FindStringSubmatch(line); m != nil {
+		p.finishPackage(m[1], StatusFail, m[2])
+		return
+	}
+	if goPkgNoTest.MatchString(line) || goBareStatus.MatchString(line) {
+		return
+	}
+
+	if p.current != "" {
+		p.b.output(p.b.test("", p.current), strings.TrimSpace(line))
+	}
+}
+
+func (p *GoTestParser) finishPackage(name string, status Status, elapsed string) {
+	p.b.rename("", name)
+	pkg := p.b.pkg(name)
+	pkg.Status = status
+	pkg.Elapsed, _ = strconv.ParseFloat(elapsed, 64)
+	p.current = ""
+}
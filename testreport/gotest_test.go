@@ -0,0 +1,118 @@
+package testreport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func feedAll(p Parser, output string) {
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		p.Feed(line)
+	}
+}
+
+const goTestJSON = `{"Action":"start","Package":"example.com/calc"}
+{"Action":"run","Package":"example.com/calc","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/calc","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestAdd","Output":"--- PASS: TestAdd (0.00s)\n"}
+{"Action":"pass","Package":"example.com/calc","Test":"TestAdd","Elapsed":0.001}
+{"Action":"run","Package":"example.com/calc","Test":"TestDiv"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"=== RUN   TestDiv\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"    calc_test.go:12: division by zero\n"}
+{"Action":"output","Package":"example.com/calc","Test":"TestDiv","Output":"--- FAIL: TestDiv (0.02s)\n"}
+{"Action":"fail","Package":"example.com/calc","Test":"TestDiv","Elapsed":0.02}
+{"Action":"run","Package":"example.com/calc","Test":"TestMod"}
+{"Action":"skip","Package":"example.com/calc","Test":"TestMod","Elapsed":0}
+{"Action":"output","Package":"example.com/calc","Output":"FAIL\n"}
+{"Action":"fail","Package":"example.com/calc","Elapsed":0.03}
+{"Action":"skip","Package":"example.com/empty","Elapsed":0}`
+
+func TestGoTestParser_JSON(t *testing.T) {
+	p := NewGoTestParser()
+	feedAll(p, goTestJSON)
+
+	r := p.Report()
+
+	assert.Equal(t, FormatGo, r.Format)
+	assert.Equal(t, Summary{Total: 3, Passed: 1, Failed: 1, Skipped: 1}, r.Summary)
+	require.Len(t, r.Packages, 2)
+	assert.Equal(t, StatusFail, r.Packages[0].Status)
+	assert.Equal(t, 0.03, r.Packages[0].Elapsed)
+	assert.Equal(t, StatusSkip, r.Packages[1].Status)
+
+	failures := r.Failures()
+	require.Len(t, failures, 1)
+	assert.Equal(t, "example.com/calc", failures[0].Package)
+	assert.Equal(t, "TestDiv", failures[0].Name)
+	assert.Equal(t, 0.02, failures[0].Elapsed)
+	assert.Equal(t, []string{"    calc_test.go:12: division by zero"}, failures[0].Output)
+	assert.Empty(t, r.Packages[0].Tests[0].Output)
+}
+
+const goTestVerbose = `=== RUN   TestAdd
+--- PASS: TestAdd (0.00s)
+=== RUN   TestDiv
+=== RUN   TestDiv/by_zero
+    calc_test.go:12: division by zero
+--- FAIL: TestDiv (0.02s)
+    --- FAIL: TestDiv/by_zero (0.01s)
+FAIL
+FAIL	example.com/calc	0.031s
+=== RUN   TestParse
+--- SKIP: TestParse (0.00s)
+    parse_test.go:5: not implemented
+PASS
+ok  	example.com/parse	0.004s
+?   	example.com/cmd	[no test files]`
+
+func TestGoTestParser_Verbose(t *testing.T) {
+	p := NewGoTestParser()
+	feedAll(p, goTestVerbose)
+
+	r := p.Report()
+
+	require.Len(t, r.Packages, 2)
+	calc := r.Packages[0]
+	assert.Equal(t, "example.com/calc", calc.Name)
+	assert.Equal(t, StatusFail, calc.Status)
+	assert.Equal(t, 0.031, calc.Elapsed)
+	require.Len(t, calc.Tests, 3)
+
+	parse := r.Packages[1]
+	assert.Equal(t, "example.com/parse", parse.Name)
+	assert.Equal(t, StatusPass, parse.Status)
+	require.Len(t, parse.Tests, 1)
+	assert.Equal(t, StatusSkip, parse.Tests[0].Status)
+
+	assert.Equal(t, Summary{Total: 4, Passed: 1, Failed: 2, Skipped: 1}, r.Summary)
+
+	failures := r.Failures()
+	require.Len(t, failures, 2)
+	assert.Equal(t, "TestDiv", failures[0].Name)
+	assert.Equal(t, "TestDiv/by_zero", failures[1].Name)
+	assert.Equal(t, []string{"calc_test.go:12: division by zero"}, failures[1].Output)
+}
+
+func TestGoTestParser_RunningTestsWithoutPackageLine(t *testing.T) {
+	p := NewGoTestParser()
+	feedAll(p, "=== RUN   TestSlow\n    slow_test.go:3: still going")
+
+	r := p.Report()
+
+	require.Len(t, r.Packages, 1)
+	assert.Equal(t, "", r.Packages[0].Name)
+	assert.Equal(t, Summary{Total: 1, Running: 1}, r.Summary)
+}
+
+func TestGoTestParser_IgnoresUnrelatedOutput(t *testing.T) {
+	p := NewGoTestParser()
+	feedAll(p, "compiling...\n{not json}\nlistening on :8080")
+
+	r := p.Report()
+
+	assert.Empty(t, r.Packages)
+	assert.Equal(t, Summary{}, r.Summary)
+}
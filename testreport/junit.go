@@ -0,0 +1,117 @@
+package testreport
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const FormatJUnit = "junit"
+
+type junitSuite struct {
+	Name   string       `xml:"name,attr"`
+	Time   string       `xml:"time,attr"`
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failures  []junitResult `xml:"failure"`
+	Errors    []junitResult `xml:"error"`
+	Skipped   *junitResult  `xml:"skipped"`
+}
+
+type junitResult struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// ParseJUnit reads a JUnit XML document, rooted at either <testsuites> or a
+// single <testsuite>. Each suite becomes a package; errors count as failures.
+func ParseJUnit(r io.Reader) (Report, error) {
+	var root struct {
+		XMLName xml.Name
+		junitSuite
+	}
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return Report{}, fmt.Errorf("parse junit: %w", err)
+	}
+
+	var suites []junitSuite
+	switch root.XMLName.Local {
+	case "testsuites":
+		suites = root.Suites
+	case "testsuite":
+		suites = []junitSuite{root.junitSuite}
+	default:
+		return Report{}, fmt.Errorf("parse junit: unexpected root element <%s>", root.XMLName.Local)
+	}
+
+	b := newBuilder(FormatJUnit)
+	for _, s := range suites {
+		addJUnitSuite(b, s)
+	}
+	return b.report(), nil
+}
+
+func addJUnitSuite(b *builder, s junitSuite) {
+	for _, nested := range s.Suites {
+		addJUnitSuite(b, nested)
+	}
+	if len(s.Cases) == 0 {
+		return
+	}
+
+	pkg := b.pkg(s.Name)
+	pkg.Status = StatusPass
+	pkg.Elapsed = parseSeconds(s.Time)
+
+	for _, c := range s.Cases {
+		name := c.Name
+		if c.Classname != "" && c.Classname != s.Name {
+			name = c.Classname + "." + c.Name
+		}
+		tc := b.test(s.Name, name)
+
+		problems := slices.Concat(c.Failures, c.Errors)
+		switch {
+		case len(problems) > 0:
+			for _, p := range problems {
+				for _, line := range junitOutput(p) {
+					b.output(tc, line)
+				}
+			}
+			b.finish(tc, StatusFail, parseSeconds(c.Time))
+			pkg.Status = StatusFail
+		case c.Skipped != nil:
+			b.finish(tc, StatusSkip, parseSeconds(c.Time))
+		default:
+			b.finish(tc, StatusPass, parseSeconds(c.Time))
+		}
+	}
+}
+
+func junitOutput(r junitResult) []string {
+	var lines []string
+	if r.Message != "" {
+		lines = append(lines, r.Message)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(r.Body), "\n") {
+		if line = strings.TrimRight(line, " \t\r"); line != "" && line != r.Message {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+func parseSeconds(s string) float64 {
+	f, _ := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	return f
+}
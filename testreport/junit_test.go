@@ -0,0 +1,65 @@
+package testreport
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const junitXML = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="pytest" tests="4" failures="1" errors="1" skipped="1" time="1.250">
+    <testcase classname="tests.test_api" name="test_list" time="0.100"/>
+    <testcase classname="tests.test_api" name="test_create" time="0.200">
+      <failure message="assert 404 == 201">tests/test_api.py:22: AssertionError
+assert 404 == 201</failure>
+    </testcase>
+    <testcase classname="tests.test_api" name="test_delete" time="0.000">
+      <skipped message="not implemented"/>
+    </testcase>
+    <testcase classname="tests.test_db" name="test_connect" time="0.950">
+      <error message="connection refused"/>
+    </testcase>
+  </testsuite>
+  <testsuite name="empty" tests="0"/>
+</testsuites>`
+
+func TestParseJUnit(t *testing.T) {
+	r, err := ParseJUnit(strings.NewReader(junitXML))
+	require.NoError(t, err)
+
+	assert.Equal(t, FormatJUnit, r.Format)
+	assert.Equal(t, Summary{Total: 4, Passed: 1, Failed: 2, Skipped: 1}, r.Summary)
+	require.Len(t, r.Packages, 1)
+	assert.Equal(t, "pytest", r.Packages[0].Name)
+	assert.Equal(t, StatusFail, r.Packages[0].Status)
+	assert.Equal(t, 1.25, r.Packages[0].Elapsed)
+
+	failures := r.Failures()
+	require.Len(t, failures, 2)
+	assert.Equal(t, "tests.test_api.test_create", failures[0].Name)
+	assert.Equal(t, 0.2, failures[0].Elapsed)
+	assert.Equal(t, []string{"assert 404 == 201", "tests/test_api.py:22: AssertionError"}, failures[0].Output)
+	assert.Equal(t, []string{"connection refused"}, failures[1].Output)
+}
+
+func TestParseJUnit_SingleSuite(t *testing.T) {
+	doc := `<testsuite name="calc"><testcase classname="calc" name="adds"/></testsuite>`
+
+	r, err := ParseJUnit(strings.NewReader(doc))
+	require.NoError(t, err)
+
+	require.Len(t, r.Packages, 1)
+	assert.Equal(t, StatusPass, r.Packages[0].Status)
+	assert.Equal(t, "adds", r.Packages[0].Tests[0].Name)
+}
+
+func TestParseJUnit_Invalid(t *testing.T) {
+	_, err := ParseJUnit(strings.NewReader("<html></html>"))
+	assert.Error(t, err)
+
+	_, err = ParseJUnit(strings.NewReader("not xml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,202 @@
+package testreport
+
+import (
+	"slices"
+	"sync"
+)
+
+type Status string
+
+const (
+	StatusRunning Status = "running"
+	StatusPass    Status = "pass"
+	StatusFail    Status = "fail"
+	StatusSkip    Status = "skip"
+)
+
+// maxTestOutput bounds the output kept per test so a chatty test cannot grow
+// the report without limit.
+const maxTestOutput = 200
+
+type TestCase struct {
+	Package string   `json:"package"`
+	Name    string   `json:"name"`
+	Status  Status   `json:"status"`
+	Elapsed float64  `json:"elapsed"`
+	Output  []string `json:"output,omitempty"`
+}
+
+type Package struct {
+	Name    string     `json:"name"`
+	Status  Status     `json:"status"`
+	Elapsed float64    `json:"elapsed"`
+	Tests   []TestCase `json:"tests"`
+}
+
+type Summary struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	Running int `json:"running"`
+}
+
+type Report struct {
+	Format   string    `json:"format"`
+	Packages []Package `json:"packages"`
+	Summary  Summary   `json:"summary"`
+
+	// Error explains why a results artifact could not be read.
+	Error string `json:"error,omitempty"`
+}
+
+// Failures returns every failing test across packages.
+func (r Report) Failures() []TestCase {
+	failed := []TestCase{}
+	for _, pkg := range r.Packages {
+		for _, tc := range pkg.Tests {
+			if tc.Status == StatusFail {
+				failed = append(failed, tc)
+			}
+		}
+	}
+	return failed
+}
+
+// Parser consumes output lines and maintains a test report.
+type Parser interface {
+	Feed(line string)
+	Report() Report
+}
+
+// builder accumulates packages and tests in first-seen order. Output is kept
+// only for tests that have not passed or been skipped.
+type builder struct {
+	mu       sync.Mutex
+	format   string
+	packages []*Package
+	tests    map[string]*TestCase
+	order    map[string][]string
+}
+
+func newBuilder(format string) *builder {
+	return &builder{
+		format: format,
+		tests:  make(map[string]*TestCase),
+		order:  make(map[string][]string),
+	}
+}
+
+func (b *builder) pkg(name string) *Package {
+	for _, p := range b.packages {
+		if p.Name == name {
+			return p
+		}
+	}
+	p := &Package{Name: name, Status: StatusRunning}
+	b.packages = append(b.packages, p)
+	return p
+}
+
+func (b *builder) test(pkg, name string) *TestCase {
+	key := pkg + "\x00" + name
+	if tc, ok := b.tests[key]; ok {
+		return tc
+	}
+	b.pkg(pkg)
+	tc := &TestCase{Package: pkg, Name: name, Status: StatusRunning}
+	b.tests[key] = tc
+	b.order[pkg] = append(b.order[pkg], name)
+	return tc
+}
+
+func (b *builder) finish(tc *TestCase, status Status, elapsed float64) {
+	tc.Status = status
+	tc.Elapsed = elapsed
+	if status != StatusFail {
+		tc.Output = nil
+	}
+}
+
+func (b *builder) output(tc *TestCase, line string) {
+	if tc.Status == StatusPass || tc.Status == StatusSkip {
+		return
+	}
+	if len(tc.Output) >= maxTestOutput {
+		return
+	}
+	tc.Output = append(tc.Output, line)
+}
+
+// rename moves the tests recorded under package from to package to. It is
+// used by formats that only name the package after its tests have run.
+func (b *builder) rename(from, to string) {
+	i := slices.IndexFunc(b.packages, func(p *Package) bool { return p.Name == from })
+	if i < 0 {
+		return
+	}
+	b.packages = slices.Delete(b.packages, i, i+1)
+	b.pkg(to)
+
+	for _, name := range b.order[from] {
+		tc := b.tests[from+"\x00"+name]
+		delete(b.tests, from+"\x00"+name)
+		tc.Package = to
+		b.tests[to+"\x00"+name] = tc
+		b.order[to] = append(b.order[to], name)
+	}
+	delete(b.order, from)
+}
+
+func (b *builder) report() Report {
+	r := Report{Format: b.format, Packages: make([]Package, 0, len(b.packages))}
+	for _, p := range b.packages {
+		pkg := *p
+		pkg.Tests = make([]TestCase, 0, len(b.order[p.Name]))
+		for _, name := range b.order[p.Name] {
+			tc := *b.tests[p.Name+"\x00"+name]
+			tc.Output = slices.Clone(tc.Output)
+			pkg.Tests = append(pkg.Tests, tc)
+
+			r.Summary.Total++
+			switch tc.Status {
+			case StatusPass:
+				r.Summary.Passed++
+			case StatusFail:
+				r.Summary.Failed++
+			case StatusSkip:
+				r.Summary.Skipped++
+			default:
+				r.Summary.Running++
+			}
+		}
+		r.Packages = append(r.Packages, pkg)
+	}
+	return r
+}
+
+// Artifact is a Parser for reports produced outside the output stream, such
+// as a results file read once the run has finished. Fed lines are ignored.
+type Artifact struct {
+	mu     sync.Mutex
+	report Report
+}
+
+func NewArtifact(format string) *Artifact {
+	return &Artifact{report: Report{Format: format, Packages: []Package{}}}
+}
+
+func (a *Artifact) Feed(string) {}
+
+func (a *Artifact) Report() Report {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.report
+}
+
+// Set replaces the report, typically after parsing the artifact.
+func (a *Artifact) Set(r Report) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.report = r
+}
@@ -0,0 +1,117 @@
+package testreport
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const FormatTAP = "tap"
+
+var (
+	tapTestRe = regexp.MustCompile(`^(not ok|ok)\b\s*(\d+)?\s*(?:-\s*)?([^#]*?)\s*(?:#\s*(?i:(skip|todo))\S*.*)?$`)
+	tapPlanRe = regexp.MustCompile(`^1\.\.(\d+)`)
+)
+
+// TAPParser builds a report from Test Anything Protocol output. TAP has no
+// notion of packages, so every top-level test point is reported under one
+// unnamed package. Indented test points belong to subtests and are summarised
+// by their parent. YAML blocks and comments following a failing test point
+// are kept as its output.
+type TAPParser struct {
+	b      *builder
+	last   *TestCase
+	inYAML bool
+	points int
+	plan   int
+	failed bool
+}
+
+func NewTAPParser() *TAPParser {
+	return &TAPParser{b: newBuilder(FormatTAP)}
+}
+
+func (p *TAPParser) Feed(line string) {
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+
+	trimmed := strings.TrimSpace(line)
+	if p.inYAML {
+		if trimmed == "..." {
+			p.inYAML = false
+		} else if p.last != nil {
+			p.b.output(p.last, trimmed)
+		}
+		return
+	}
+
+	if m := tapTestRe.FindStringSubmatch(line); m != nil {
+		p.feedPoint(m)
+		return
+	}
+
+	switch {
+	case trimmed == "---" && p.last != nil:
+		p.inYAML = true
+	case strings.HasPrefix(trimmed, "#") && p.last != nil:
+		if comment := strings.TrimSpace(strings.TrimPrefix(trimmed, "#")); comment != "" {
+			p.b.output(p.last, comment)
+		}
+	case tapPlanRe.MatchString(line):
+		// The plan comes either first or last; a trailing plan ends the run.
+		p.last = nil
+		p.plan, _ = strconv.Atoi(tapPlanRe.FindStringSubmatch(line)[1])
+		if p.points > 0 {
+			p.finishPackage()
+		}
+	}
+}
+
+func (p *TAPParser) Report() Report {
+	p.b.mu.Lock()
+	defer p.b.mu.Unlock()
+	return p.b.report()
+}
+
+func (p *TAPParser) feedPoint(m []string) {
+	p.points++
+	name := m[3]
+	if name == "" {
+		num := m[2]
+		if num == "" {
+			num = strconv.Itoa(p.points)
+		}
+		name = "test " + num
+	}
+
+	status := StatusPass
+	if m[1] == "not ok" {
+		status = StatusFail
+	}
+	switch strings.ToLower(m[4]) {
+	case "skip":
+		status = StatusSkip
+	case "todo":
+		// A TODO test point is expected to fail and does not fail the run.
+		status = StatusSkip
+	}
+	if status == StatusFail {
+		p.failed = true
+	}
+
+	tc := p.b.test("", name)
+	p.b.finish(tc, status, 0)
+	p.last = tc
+
+	if p.plan > 0 && p.points >= p.plan {
+		p.finishPackage()
+	}
+}
+
+func (p *TAPParser) finishPackage() {
+	pkg := p.b.pkg("")
+	pkg.Status = StatusPass
+	if p.failed {
+		pkg.Status = StatusFail
+	}
+}
@@ -0,0 +1,63 @@
+package testreport
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const tapOutput = `TAP version 13
+1..5
+ok 1 - parses input
+not ok 2 - rejects empty input
+  ---
+  message: 'expected error'
+  at: parser.test.js:14
+  ...
+ok 3 - reads config # SKIP no config file
+not ok 4 - summarizes # TODO not written yet
+# Subtest: nested
+    ok 1 - inner
+    1..1
+ok 5 - nested`
+
+func TestTAPParser(t *testing.T) {
+	p := NewTAPParser()
+	feedAll(p, tapOutput)
+
+	r := p.Report()
+
+	assert.Equal(t, FormatTAP, r.Format)
+	assert.Equal(t, Summary{Total: 5, Passed: 2, Failed: 1, Skipped: 2}, r.Summary)
+	require.Len(t, r.Packages, 1)
+	assert.Equal(t, StatusFail, r.Packages[0].Status)
+
+	failures := r.Failures()
+	require.Len(t, failures, 1)
+	assert.Equal(t, "rejects empty input", failures[0].Name)
+	assert.Equal(t, []string{"message: 'expected error'", "at: parser.test.js:14"}, failures[0].Output)
+}
+
+func TestTAPParser_TrailingPlan(t *testing.T) {
+	p := NewTAPParser()
+	feedAll(p, "ok 1 - first\nok 2\n")
+
+	assert.Equal(t, StatusRunning, p.Report().Packages[0].Status)
+
+	p.Feed("1..2")
+
+	r := p.Report()
+	assert.Equal(t, StatusPass, r.Packages[0].Status)
+	assert.Equal(t, "test 2", r.Packages[0].Tests[1].Name)
+	assert.Equal(t, Summary{Total: 2, Passed: 2}, r.Summary)
+}
+
+func TestTAPParser_CommentsAfterFailure(t *testing.T) {
+	p := NewTAPParser()
+	feedAll(p, "not ok 1 - adds numbers\n# expected 3\n# got 4\nok 2 - subtracts\n# done\n1..2")
+
+	failures := p.Report().Failures()
+	require.Len(t, failures, 1)
+	assert.Equal(t, []string{"expected 3", "got 4"}, failures[0].Output)
+}
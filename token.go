@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/cloud-gt/ai-sensors/auth"
+)
+
+const tokenUsage = `usage: ai-sensors token <command> [flags]
+
+commands:
+  create -name NAME [-scopes read,control,define]   create a token and print its secret
+  list [-json]                                      list tokens
+  revoke NAME|ID                                    delete a token
+
+every command accepts -file PATH (default %s)
+`
+
+// runToken implements the token subcommand and returns the exit code.
+func runToken(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintf(stderr, tokenUsage, auth.DefaultPath())
+		return 2
+	}
+
+	fs := flag.NewFlagSet("token "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	file := fs.String("file", auth.DefaultPath(), "token file")
+
+	switch args[0] {
+	case "create":
+		name := fs.String("name", "", "token name")
+		scopes := fs.String("scopes", string(auth.ScopeRead), "comma-separated scopes: read, control, define")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		parsed, err := auth.ParseScopes(*scopes)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 2
+		}
+		token, secret, err := auth.NewTokenStore(*file).Create(*name, parsed)
+		if err != nil {
+			fmt.Fprintln(stderr, "create token:", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "Created token %q (%s) with scopes %s. The secret is shown only once:\n",
+			token.Name, token.ID, joinScopes(token.Scopes))
+		fmt.Fprintln(stdout, secret)
+		return 0
+
+	case "list":
+		asJSON := fs.Bool("json", false, "print JSON")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		tokens, err := auth.NewTokenStore(*file).List()
+		if err != nil {
+			fmt.Fprintln(stderr, "list tokens:", err)
+			return 1
+		}
+		if *asJSON {
+			for i := range tokens {
+				tokens[i].Hash = ""
+			}
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			_ = enc.Encode(map[string][]auth.Token{"tokens": tokens})
+			return 0
+		}
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tNAME\tSCOPES\tCREATED")
+		for _, t := range tokens {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.ID, t.Name, joinScopes(t.Scopes), t.CreatedAt.Format("2006-01-02 15:04"))
+		}
+		_ = tw.Flush()
+		return 0
+
+	case "revoke":
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if fs.NArg() != 1 {
+			fmt.Fprintln(stderr, "usage: ai-sensors token revoke NAME|ID")
+			return 2
+		}
+		if err := auth.NewTokenStore(*file).Revoke(fs.Arg(0)); err != nil {
+			fmt.Fprintln(stderr, "revoke token:", err)
+			return 1
+		}
+		fmt.Fprintf(stderr, "Revoked token %s\n", fs.Arg(0))
+		return 0
+
+	default:
+		fmt.Fprintf(stderr, tokenUsage, auth.DefaultPath())
+		return 2
+	}
+}
+
+func joinScopes(scopes []auth.Scope) string {
+	parts := make([]string, len(scopes))
+	for i, s := range scopes {
+		parts[i] = string(s)
+	}
+	return strings.Join(parts, ",")
+}
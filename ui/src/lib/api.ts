@@ -1,12 +1,53 @@
+import { goto } from '$app/navigation';
+import { base } from '$app/paths';
 import type {
 	Command,
 	CommandListResponse,
 	StatusResponse,
 	OutputResponse,
-	StartResponse
+	StartResponse,
+	MetricsResponse
 } from './types';
 
 const BASE = '/commands';
+const TOKEN_KEY = 'ai-sensors-token';
+
+// The server accepts POSTs only as JSON, even those without a body.
+const JSON_HEADERS = { 'Content-Type': 'application/json' };
+
+export class UnauthorizedError extends Error {
+	constructor(message = 'Authentication required') {
+		super(message);
+		this.name = 'UnauthorizedError';
+	}
+}
+
+export function getToken(): string | null {
+	return localStorage.getItem(TOKEN_KEY);
+}
+
+export function setToken(token: string): void {
+	localStorage.setItem(TOKEN_KEY, token);
+}
+
+export function clearToken(): void {
+	localStorage.removeItem(TOKEN_KEY);
+}
+
+// apiFetch adds the stored bearer token and sends the user to the login
+// page when the server rejects it.
+async function apiFetch(url: string, init: RequestInit = {}): Promise<Response> {
+	const headers = new Headers(init.headers);
+	const token = getToken();
+	if (token) headers.set('Authorization', `Bearer ${token}`);
+
+	const res = await fetch(url, { ...init, headers });
+	if (res.status === 401) {
+		await goto(`${base}/login`);
+		throw new UnauthorizedError();
+	}
+	return res;
+}
 
 async function handleResponse<T>(res: Response): Promise<T> {
 	if (!res.ok) {
@@ -17,12 +58,12 @@ async function handleResponse<T>(res: Response): Promise<T> {
 }
 
 export async function listCommands(): Promise<Command[]> {
-	const data = await handleResponse<CommandListResponse>(await fetch(BASE));
+	const data = await handleResponse<CommandListResponse>(await apiFetch(BASE));
 	return data.commands ?? [];
 }
 
 export async function getCommand(id: string): Promise<Command> {
-	return handleResponse<Command>(await fetch(`${BASE}/${id}`));
+	return handleResponse<Command>(await apiFetch(`${BASE}/${id}`));
 }
 
 export async function createCommand(
@@ -31,16 +72,16 @@ export async function createCommand(
 	workDir: string
 ): Promise<Command> {
 	return handleResponse<Command>(
-		await fetch(BASE, {
+		await apiFetch(BASE, {
 			method: 'POST',
-			headers: { 'Content-Type': 'application/json' },
+			headers: JSON_HEADERS,
 			body: JSON.stringify({ name, command, work_dir: workDir })
 		})
 	);
 }
 
 export async function deleteCommand(id: string): Promise<void> {
-	const res = await fetch(`${BASE}/${id}`, { method: 'DELETE' });
+	const res = await apiFetch(`${BASE}/${id}`, { method: 'DELETE' });
 	if (!res.ok) {
 		const body = await res.json().catch(() => ({ error: 'Unknown error' }));
 		throw new Error(body.error || `HTTP ${res.status}`);
@@ -49,29 +90,41 @@ export async function deleteCommand(id: string): Promise<void> {
 
 export async function startCommand(id: string): Promise<boolean> {
 	const data = await handleResponse<StartResponse>(
-		await fetch(`${BASE}/${id}/start`, { method: 'POST' })
+		await apiFetch(`${BASE}/${id}/start`, { method: 'POST', headers: JSON_HEADERS })
 	);
 	return data.started;
 }
 
 export async function stopCommand(id: string): Promise<void> {
-	const res = await fetch(`${BASE}/${id}/stop`, { method: 'POST' });
+	const res = await apiFetch(`${BASE}/${id}/stop`, { method: 'POST', headers: JSON_HEADERS });
 	if (!res.ok) {
 		const body = await res.json().catch(() => ({ error: 'Unknown error' }));
 		throw new Error(body.error || `HTTP ${res.status}`);
 	}
 }
 
-export async function getStatus(id: string): Promise<string> {
-	const data = await handleResponse<StatusResponse>(
-		await fetch(`${BASE}/${id}/status`)
-	);
-	return data.status;
+export async function getStatus(id: string): Promise<StatusResponse> {
+	return handleResponse<StatusResponse>(await apiFetch(`${BASE}/${id}/status`));
 }
 
 export async function getOutput(id: string, lines?: number): Promise<string[]> {
 	const url =
 		lines !== undefined ? `${BASE}/${id}/output?lines=${lines}` : `${BASE}/${id}/output`;
-	const data = await handleResponse<OutputResponse>(await fetch(url));
+	const data = await handleResponse<OutputResponse>(await apiFetch(url));
 	return data.lines ?? [];
 }
+
+// getMetrics returns process metrics; pass the time of the last sample seen
+// to fetch only newer samples.
+export async function getMetrics(id: string, since?: string): Promise<MetricsResponse> {
+	const url = since
+		? `${BASE}/${id}/metrics?since=${encodeURIComponent(since)}`
+		: `${BASE}/${id}/metrics`;
+	return handleResponse<MetricsResponse>(await apiFetch(url));
+}
+
+// verifyToken checks a token against the server without storing it.
+export async function verifyToken(token: string): Promise<boolean> {
+	const res = await fetch(BASE, { headers: { Authorization: `Bearer ${token}` } });
+	return res.ok;
+}
@@ -1 +1,7 @@
 // place files you want to import through the `$lib` alias in this folder.
+
+const activeStatuses = ['running', 'starting', 'ready', 'unhealthy'];
+
+export function isActive(status: string): boolean {
+	return activeStatuses.includes(status);
+}
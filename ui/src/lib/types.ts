@@ -1,16 +1,89 @@
+export interface ReadinessCheck {
+	type: 'tcp' | 'http' | 'log';
+	address?: string;
+	url?: string;
+	pattern?: string;
+}
+
+export interface Readiness {
+	checks: ReadinessCheck[];
+	interval?: string;
+	timeout?: string;
+}
+
+export interface Watch {
+	include?: string[];
+	exclude?: string[];
+	debounce?: string;
+	mode?: 'restart' | 'rerun_if_idle';
+}
+
+export type Severity = 'error' | 'warning' | 'info';
+
+export interface MatcherDefinition {
+	name: string;
+	severity?: Severity;
+	patterns?: { regexp: string; loop?: boolean }[];
+}
+
+export interface TestResults {
+	format: 'go' | 'tap' | 'junit';
+	path?: string;
+}
+
+export interface Normalization {
+	disable?: ('timestamps' | 'durations' | 'temp_paths' | 'hex_addresses')[];
+	replace?: { regexp: string; replace?: string }[];
+}
+
+export interface EnvVar {
+	name: string;
+	value?: string;
+	secret?: boolean;
+}
+
+export interface RedactConfig {
+	disable?: ('aws_keys' | 'bearer_tokens' | 'private_keys' | 'secret_env')[];
+	patterns?: string[];
+}
+
+export interface Limits {
+	memory_mb?: number;
+	cpu?: number;
+	processes?: number;
+	open_files?: number;
+	timeout?: string;
+}
+
 export interface Command {
 	id: string;
 	name: string;
 	command: string;
 	work_dir: string;
+	readiness?: Readiness;
+	depends_on?: string[];
+	tags?: string[];
+	autostart?: boolean;
+	watch?: Watch;
+	matchers?: MatcherDefinition[];
+	tests?: TestResults;
+	normalize?: Normalization;
+	dedup?: boolean;
+	env?: EnvVar[];
+	redact?: RedactConfig;
+	limits?: Limits;
 }
 
 export interface CommandListResponse {
 	commands: Command[];
 }
 
+export type Status = 'running' | 'starting' | 'ready' | 'unhealthy' | 'stopped' | 'not_started';
+
 export interface StatusResponse {
-	status: 'running' | 'stopped' | 'not_started';
+	status: Status;
+	autostarted: boolean;
+	watching: boolean;
 }
 
 export interface OutputResponse {
@@ -24,3 +97,144 @@ export interface StartResponse {
 export interface ErrorResponse {
 	error: string;
 }
+
+export interface Run {
+	number: number;
+	trigger: 'manual' | 'autostart' | 'dependency' | 'watch';
+	changes?: string[];
+	started_at: string;
+	ended_at?: string;
+	exit_code?: number;
+	error?: string;
+	stopped?: boolean;
+	termination?: 'timeout' | 'memory_limit' | 'process_limit';
+	limits_error?: string;
+	tests?: TestSummary;
+}
+
+export interface RunsResponse {
+	runs: Run[];
+}
+
+export interface DiffHunk {
+	from_start: number;
+	from_lines: number;
+	to_start: number;
+	to_lines: number;
+	lines: { op: 'equal' | 'insert' | 'delete'; text: string }[];
+}
+
+export interface RunDiff {
+	from: number;
+	to: number;
+	added: number;
+	removed: number;
+	hunks: DiffHunk[];
+}
+
+export interface RunResult {
+	run: Run;
+	exit_code?: number;
+	duration_ms: number;
+	timed_out: boolean;
+	output: string[];
+}
+
+export type TestStatus = 'running' | 'pass' | 'fail' | 'skip';
+
+export interface TestCase {
+	package: string;
+	name: string;
+	status: TestStatus;
+	elapsed: number;
+	output?: string[];
+}
+
+export interface TestPackage {
+	name: string;
+	status: TestStatus;
+	elapsed: number;
+	tests: TestCase[];
+}
+
+export interface TestSummary {
+	total: number;
+	passed: number;
+	failed: number;
+	skipped: number;
+	running: number;
+}
+
+export interface TestReport {
+	format: string;
+	packages: TestPackage[];
+	summary: TestSummary;
+	error?: string;
+}
+
+export interface Diagnostic {
+	file?: string;
+	line?: number;
+	column?: number;
+	severity: Severity;
+	code?: string;
+	message: string;
+	source: string;
+	count: number;
+}
+
+export interface DiagnosticsResponse {
+	diagnostics: Diagnostic[];
+}
+
+export interface Digest {
+	text: string;
+	total_lines: number;
+	shown_lines: number;
+	errors: number;
+	warnings: number;
+	elided: {
+		lines: number;
+		ranges: { from: number; to: number }[];
+		repeated: number;
+		errors: number;
+		warnings: number;
+		truncated: number;
+	};
+}
+
+export interface OutputEntry {
+	text: string;
+	count: number;
+	first: string;
+	last: string;
+}
+
+export interface MetricsSample {
+	time: string;
+	cpu_percent: number;
+	rss_bytes: number;
+	threads: number;
+	fds: number;
+	processes: number;
+}
+
+export interface ProcessNode {
+	pid: number;
+	ppid: number;
+	name: string;
+	cpu_percent: number;
+	rss_bytes: number;
+	threads: number;
+	fds: number;
+	children?: ProcessNode[];
+}
+
+export interface MetricsSnapshot extends MetricsSample {
+	tree: ProcessNode[];
+}
+
+export interface MetricsResponse {
+	latest: MetricsSnapshot | null;
+	samples: MetricsSample[];
+}
@@ -2,15 +2,24 @@ import { sveltekit } from '@sveltejs/kit/vite';
 import tailwindcss from '@tailwindcss/vite';
 import { defineConfig } from 'vite';
 
+// Every route the API server serves outside /dashboard.
+const apiPrefixes = [
+	'/commands',
+	'/groups',
+	'/events',
+	'/webhooks',
+	'/metrics',
+	'/healthz',
+	'/readyz',
+	'/openapi.json'
+];
+
 export default defineConfig({
 	plugins: [tailwindcss(), sveltekit()],
 	server: {
 		port: 5173,
-		proxy: {
-			'/commands': {
-				target: 'http://localhost:3000',
-				changeOrigin: true
-			}
-		}
+		proxy: Object.fromEntries(
+			apiPrefixes.map((prefix) => [prefix, { target: 'http://127.0.0.1:3000', changeOrigin: true }])
+		)
 	}
 });
@@ -0,0 +1,55 @@
+package watcher
+
+import (
+	"errors"
+	"path"
+	"strings"
+)
+
+var ErrInvalidPattern = errors.New("invalid glob pattern")
+
+// ValidatePattern reports whether pattern can be used by Match.
+func ValidatePattern(pattern string) error {
+	if pattern == "" {
+		return ErrInvalidPattern
+	}
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return ErrInvalidPattern
+		}
+	}
+	return nil
+}
+
+// Match reports whether the slash-separated relative path rel matches
+// pattern. A pattern without a slash is matched against the last element of
+// rel only ("*.go"). Otherwise it is matched segment by segment, where "**"
+// matches any number of segments ("web/src/**/*.ts").
+func Match(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchSegments(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchSegments(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,38 @@
+package watcher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/sub/file.go", true},
+		{"*.go", "pkg/file.ts", false},
+		{"pkg/*.go", "pkg/file.go", true},
+		{"pkg/*.go", "pkg/sub/file.go", false},
+		{"pkg/**/*.go", "pkg/file.go", true},
+		{"pkg/**/*.go", "pkg/a/b/file.go", true},
+		{"**/testdata/**", "x/testdata/y/z.txt", true},
+		{"tmp/**", "tmp", true},
+		{"tmp/**", "tmpfile", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, Match(tt.pattern, tt.path))
+		})
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	assert.NoError(t, ValidatePattern("src/**/*.ts"))
+	assert.ErrorIs(t, ValidatePattern(""), ErrInvalidPattern)
+	assert.ErrorIs(t, ValidatePattern("[a-"), ErrInvalidPattern)
+}
@@ -0,0 +1,178 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const defaultDebounce = 300 * time.Millisecond
+
+var (
+	ErrEmptyRoot = errors.New("watch root cannot be empty")
+	ErrNilFunc   = errors.New("change callback cannot be nil")
+)
+
+// Directories that are never watched; they are large and rarely relevant.
+var skippedDirs = []string{".git", "node_modules"}
+
+type Config struct {
+	Root     string
+	Include  []string
+	Exclude  []string
+	Debounce time.Duration
+}
+
+// Watcher reports batches of changed files below a root directory, relative
+// to it, once no further change happened for the debounce interval.
+type Watcher struct {
+	config Config
+}
+
+func New(cfg Config) (*Watcher, error) {
+	if cfg.Root == "" {
+		return nil, ErrEmptyRoot
+	}
+	for _, p := range slices.Concat(cfg.Include, cfg.Exclude) {
+		if err := ValidatePattern(p); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Debounce <= 0 {
+		cfg.Debounce = defaultDebounce
+	}
+
+	return &Watcher{config: cfg}, nil
+}
+
+// Run watches until ctx is cancelled, calling onChange with the sorted list of
+// changed paths after each debounced batch.
+func (w *Watcher) Run(ctx context.Context, onChange func(paths []string)) error {
+	if onChange == nil {
+		return ErrNilFunc
+	}
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = fsw.Close() }()
+
+	if err := w.addTree(fsw, w.config.Root); err != nil {
+		return err
+	}
+
+	pending := map[string]bool{}
+	timer := time.NewTimer(w.config.Debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Create) {
+				if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+					if err := w.addTree(fsw, ev.Name); err != nil {
+						slog.Warn("failed to watch new directory", "path", ev.Name, "error", err)
+					}
+					continue
+				}
+			}
+			if ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Write) {
+				continue
+			}
+			rel, ok := w.relevant(ev.Name)
+			if !ok {
+				continue
+			}
+			pending[rel] = true
+			timer.Reset(w.config.Debounce)
+
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("file watcher error", "root", w.config.Root, "error", err)
+
+		case <-timer.C:
+			if len(pending) == 0 {
+				continue
+			}
+			paths := make([]string, 0, len(pending))
+			for p := range pending {
+				paths = append(paths, p)
+			}
+			slices.Sort(paths)
+			pending = map[string]bool{}
+			onChange(paths)
+		}
+	}
+}
+
+func (w *Watcher) addTree(fsw *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == dir {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if p != w.config.Root && w.excludedDir(p) {
+			return filepath.SkipDir
+		}
+		return fsw.Add(p)
+	})
+}
+
+func (w *Watcher) excludedDir(p string) bool {
+	if slices.Contains(skippedDirs, filepath.Base(p)) {
+		return true
+	}
+	rel, err := filepath.Rel(w.config.Root, p)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	return slices.ContainsFunc(w.config.Exclude, func(pattern string) bool {
+		return Match(pattern, rel)
+	})
+}
+
+func (w *Watcher) relevant(name string) (string, bool) {
+	rel, err := filepath.Rel(w.config.Root, name)
+	if err != nil {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range w.config.Exclude {
+		if Match(pattern, rel) {
+			return "", false
+		}
+	}
+	if len(w.config.Include) == 0 {
+		return rel, true
+	}
+	for _, pattern := range w.config.Include {
+		if Match(pattern, rel) {
+			return rel, true
+		}
+	}
+	return "", false
+}
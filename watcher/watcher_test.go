@@ -0,0 +1,101 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type changeRecorder struct {
+	mu      sync.Mutex
+	batches [][]string
+}
+
+func (c *changeRecorder) record(paths []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.batches = append(c.batches, paths)
+}
+
+func (c *changeRecorder) get() [][]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([][]string{}, c.batches...)
+}
+
+func startWatcher(t *testing.T, cfg Config) *changeRecorder {
+	t.Helper()
+	w, err := New(cfg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := &changeRecorder{}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, w.Run(ctx, rec.record))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	time.Sleep(50 * time.Millisecond)
+	return rec
+}
+
+func TestWatcher_DebouncesChangesIntoOneBatch(t *testing.T) {
+	dir := t.TempDir()
+	rec := startWatcher(t, Config{Root: dir, Include: []string{"*.go"}, Debounce: 100 * time.Millisecond})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "b.go"), []byte("package b"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0644))
+
+	assert.Eventually(t, func() bool { return len(rec.get()) == 1 }, 2*time.Second, 20*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+
+	batches := rec.get()
+	require.Len(t, batches, 1)
+	assert.Equal(t, []string{"a.go", "b.go"}, batches[0])
+}
+
+func TestWatcher_WatchesNewSubdirectoriesAndHonorsExclude(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "tmp"), 0755))
+	rec := startWatcher(t, Config{Root: dir, Exclude: []string{"tmp/**"}, Debounce: 50 * time.Millisecond})
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tmp", "out.log"), []byte("x"), 0644))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "pkg"), 0755))
+	time.Sleep(50 * time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pkg", "file.go"), []byte("package pkg"), 0644))
+
+	assert.Eventually(t, func() bool {
+		for _, batch := range rec.get() {
+			for _, p := range batch {
+				if p == "pkg/file.go" {
+					return true
+				}
+			}
+		}
+		return false
+	}, 2*time.Second, 20*time.Millisecond)
+
+	for _, batch := range rec.get() {
+		assert.NotContains(t, batch, "tmp/out.log")
+	}
+}
+
+func TestWatcher_InvalidConfig(t *testing.T) {
+	_, err := New(Config{})
+	assert.ErrorIs(t, err, ErrEmptyRoot)
+
+	_, err = New(Config{Root: "/tmp", Include: []string{"[a-"}})
+	assert.ErrorIs(t, err, ErrInvalidPattern)
+}
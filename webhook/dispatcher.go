@@ -0,0 +1,337 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/redact"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAttempts = 5
+	defaultBackoff  = time.Second
+	maxBackoff      = time.Minute
+	requestTimeout  = 10 * time.Second
+	logSize         = 200
+	subscribeBuffer = 256
+)
+
+// Request headers set on every delivery.
+const (
+	HeaderTrigger   = "X-AI-Sensors-Trigger"
+	HeaderDelivery  = "X-AI-Sensors-Delivery"
+	HeaderTimestamp = "X-AI-Sensors-Timestamp"
+	HeaderSignature = "X-AI-Sensors-Signature"
+)
+
+// Signature returns the value of the signature header for a body sent at
+// timestamp: "sha256=" followed by the hex HMAC-SHA256, keyed with the
+// hook secret, of the decimal Unix timestamp, a dot and the body.
+// Receivers should recompute it and reject stale timestamps.
+func Signature(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Attempt is one try at delivering a payload.
+type Attempt struct {
+	Time       time.Time     `json:"time"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// Delivery records the attempts to send one payload to one hook.
+type Delivery struct {
+	ID        string         `json:"id"`
+	Hook      string         `json:"hook"`
+	Trigger   Trigger        `json:"trigger"`
+	CommandID uuid.UUID      `json:"command_id"`
+	Event     uint64         `json:"event"`
+	Status    DeliveryStatus `json:"status"`
+	CreatedAt time.Time      `json:"created_at"`
+	Attempts  []Attempt      `json:"attempts"`
+}
+
+// Dispatcher turns events into webhook deliveries. Each delivery is
+// retried with exponential backoff until the receiver answers 2xx, answers
+// with a status not worth retrying, or the attempts run out.
+type Dispatcher struct {
+	hooks    []Hook
+	store    *command.Store
+	client   *http.Client
+	attempts int
+	backoff  time.Duration
+
+	mu  sync.Mutex
+	log []*Delivery
+	wg  sync.WaitGroup
+}
+
+type Option func(*Dispatcher)
+
+func WithClient(client *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = client
+	}
+}
+
+// WithRetry sets how many times a delivery is attempted and the delay
+// before the first retry, which doubles on each further retry.
+func WithRetry(attempts int, backoff time.Duration) Option {
+	return func(d *Dispatcher) {
+		if attempts > 0 {
+			d.attempts = attempts
+		}
+		if backoff > 0 {
+			d.backoff = backoff
+		}
+	}
+}
+
+// New returns a dispatcher for hooks, which must be valid. store supplies
+// command names for payloads.
+func New(hooks []Hook, store *command.Store, opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		hooks:    hooks,
+		store:    store,
+		client:   &http.Client{Timeout: requestTimeout},
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+// Hooks returns the configured hooks with their secrets removed and their
+// URLs masked by MaskURL.
+func (d *Dispatcher) Hooks() []Hook {
+	hooks := make([]Hook, len(d.hooks))
+	for i, h := range d.hooks {
+		h.Secret = ""
+		h.URL = MaskURL(h.URL)
+		hooks[i] = h
+	}
+	return hooks
+}
+
+// MaskURL keeps only the scheme and host of a hook URL. Chat services put
+// the credential in the path or query, so everything after the host is
+// replaced with a placeholder.
+func MaskURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return redact.Placeholder
+	}
+	masked := u.Scheme + "://" + u.Host
+	if u.User != nil || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		masked += "/" + redact.Placeholder
+	}
+	return masked
+}
+
+// Deliveries returns the most recent deliveries, newest first.
+func (d *Dispatcher) Deliveries() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	result := make([]Delivery, 0, len(d.log))
+	for i := len(d.log) - 1; i >= 0; i-- {
+		dl := *d.log[i]
+		dl.Attempts = append([]Attempt(nil), dl.Attempts...)
+		result = append(result, dl)
+	}
+	return result
+}
+
+// Run dispatches events from bus until ctx is done, then waits for
+// deliveries in flight to give up.
+func (d *Dispatcher) Run(ctx context.Context, bus *events.Bus) {
+	defer d.wg.Wait()
+
+	var last uint64
+	for {
+		sub := bus.Subscribe(subscribeBuffer, last)
+		for open := true; open; {
+			select {
+			case <-ctx.Done():
+				sub.Close()
+				return
+			case e, ok := <-sub.C:
+				if !ok {
+					open = false
+					continue
+				}
+				last = e.ID
+				d.dispatch(ctx, e)
+			}
+		}
+		// Dropped for falling behind: resubscribe, replaying what is
+		// still in the bus history.
+	}
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context, e events.Event) {
+	trigger, ok := classify(e)
+	if !ok {
+		return
+	}
+
+	for _, h := range d.hooks {
+		if !h.wants(trigger) {
+			continue
+		}
+		dl := &Delivery{
+			ID:        uuid.NewString(),
+			Hook:      h.Name,
+			Trigger:   trigger,
+			CommandID: e.CommandID,
+			Event:     e.ID,
+			Status:    DeliveryPending,
+			CreatedAt: time.Now(),
+			Attempts:  []Attempt{},
+		}
+		body, err := json.Marshal(d.payload(dl, e))
+		if err != nil {
+			continue
+		}
+
+		d.mu.Lock()
+		d.log = append(d.log, dl)
+		if len(d.log) > logSize {
+			d.log = d.log[len(d.log)-logSize:]
+		}
+		d.mu.Unlock()
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(ctx, h, dl, body)
+		}()
+	}
+}
+
+func (d *Dispatcher) payload(dl *Delivery, e events.Event) Payload {
+	p := Payload{Delivery: dl.ID, Trigger: dl.Trigger, Time: e.Time}
+	p.Command.ID = e.CommandID
+	if cmd, err := d.store.Get(e.CommandID); err == nil {
+		p.Command.Name = cmd.Name
+	}
+	switch e.Type {
+	case events.RunExited:
+		run := e.Data.(manager.Run)
+		p.Run = &run
+	case events.CommandReady, events.CommandUnhealthy:
+		ready := e.Type == events.CommandReady
+		p.Ready = &ready
+	}
+	return p
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, h Hook, dl *Delivery, body []byte) {
+	wait := d.backoff
+	for attempt := 1; ; attempt++ {
+		a, retry := d.attempt(ctx, h, dl, body)
+
+		d.mu.Lock()
+		dl.Attempts = append(dl.Attempts, a)
+		switch {
+		case a.Error == "" && a.StatusCode < 300:
+			dl.Status = DeliveryDelivered
+		case !retry || attempt == d.attempts:
+			dl.Status = DeliveryFailed
+		}
+		status := dl.Status
+		d.mu.Unlock()
+
+		if status != DeliveryPending {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			d.mu.Lock()
+			dl.Status = DeliveryFailed
+			d.mu.Unlock()
+			return
+		case <-time.After(wait):
+		}
+		wait = min(wait*2, maxBackoff)
+	}
+}
+
+// attempt posts body once and reports whether a failure is worth retrying.
+func (d *Dispatcher) attempt(ctx context.Context, h Hook, dl *Delivery, body []byte) (Attempt, bool) {
+	a := Attempt{Time: time.Now()}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		a.Error = maskedError(err, h.URL)
+		return a, false
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ai-sensors-webhook")
+	req.Header.Set(HeaderTrigger, string(dl.Trigger))
+	req.Header.Set(HeaderDelivery, dl.ID)
+	if h.Secret != "" {
+		ts := a.Time.Unix()
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(HeaderSignature, Signature(h.Secret, ts, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		a.Error = maskedError(err, h.URL)
+		a.Duration = time.Since(a.Time)
+		return a, true
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	a.StatusCode = resp.StatusCode
+	a.Duration = time.Since(a.Time)
+	if resp.StatusCode >= 300 {
+		a.Error = resp.Status
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return a, retry
+}
+
+// maskedError returns the message of err with hookURL masked, since the
+// delivery log is readable with any token.
+func maskedError(err error, hookURL string) string {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = MaskURL(hookURL)
+	}
+	return err.Error()
+}
@@ -0,0 +1,237 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/command"
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver answers with the given status codes in turn, then 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []received
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	rc.requests = append(rc.requests, received{header: r.Header.Clone(), body: body})
+	status := http.StatusOK
+	if len(rc.statuses) > 0 {
+		status, rc.statuses = rc.statuses[0], rc.statuses[1:]
+	}
+	rc.mu.Unlock()
+	w.WriteHeader(status)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func startDispatcher(t *testing.T, hooks []Hook) (*Dispatcher, *events.Bus, command.Command) {
+	t.Helper()
+	store := command.NewStore(command.NewMemoryRepository())
+	cmd, err := store.Create(command.Command{Name: "api", Command: "false", WorkDir: "/tmp"})
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	d := New(hooks, store, WithRetry(3, 10*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		d.Run(ctx, bus)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	require.Eventually(t, func() bool { return bus.Subscribers() == 1 }, time.Second, time.Millisecond)
+	return d, bus, cmd
+}
+
+func waitForStatus(t *testing.T, d *Dispatcher, want DeliveryStatus) Delivery {
+	t.Helper()
+	var last Delivery
+	require.Eventually(t, func() bool {
+		deliveries := d.Deliveries()
+		if len(deliveries) == 0 {
+			return false
+		}
+		last = deliveries[0]
+		return last.Status == want
+	}, 5*time.Second, 10*time.Millisecond)
+	return last
+}
+
+func TestDispatcher_DeliversSignedCrash(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	d, bus, cmd := startDispatcher(t, []Hook{{Name: "bot", URL: srv.URL, Secret: "hush", On: []Trigger{TriggerCrash}}})
+
+	code := 2
+	bus.Publish(events.RunExited, cmd.ID, manager.Run{Number: 1, ExitCode: &code})
+
+	delivery := waitForStatus(t, d, DeliveryDelivered)
+	assert.Equal(t, "bot", delivery.Hook)
+	assert.Equal(t, TriggerCrash, delivery.Trigger)
+	require.Len(t, delivery.Attempts, 1)
+	assert.Equal(t, http.StatusOK, delivery.Attempts[0].StatusCode)
+
+	require.Equal(t, 1, rc.count())
+	req := rc.requests[0]
+	assert.Equal(t, "crash", req.header.Get(HeaderTrigger))
+	assert.Equal(t, delivery.ID, req.header.Get(HeaderDelivery))
+	ts, err := strconv.ParseInt(req.header.Get(HeaderTimestamp), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, Signature("hush", ts, req.body), req.header.Get(HeaderSignature))
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(req.body, &payload))
+	assert.Equal(t, TriggerCrash, payload.Trigger)
+	assert.Equal(t, cmd.ID, payload.Command.ID)
+	assert.Equal(t, "api", payload.Command.Name)
+	require.NotNil(t, payload.Run)
+	assert.Equal(t, 2, *payload.Run.ExitCode)
+}
+
+func TestDispatcher_FiltersByTrigger(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	d, bus, cmd := startDispatcher(t, []Hook{{Name: "ready", URL: srv.URL, On: []Trigger{TriggerReadiness}}})
+
+	code := 1
+	bus.Publish(events.RunExited, cmd.ID, manager.Run{ExitCode: &code})
+	bus.Publish(events.CommandUnhealthy, cmd.ID, nil)
+
+	delivery := waitForStatus(t, d, DeliveryDelivered)
+	assert.Equal(t, TriggerReadiness, delivery.Trigger)
+	assert.Len(t, d.Deliveries(), 1)
+
+	var payload Payload
+	require.NoError(t, json.Unmarshal(rc.requests[0].body, &payload))
+	require.NotNil(t, payload.Ready)
+	assert.False(t, *payload.Ready)
+	assert.Empty(t, rc.requests[0].header.Get(HeaderSignature), "unsigned without a secret")
+}
+
+func TestDispatcher_IgnoresStoppedRuns(t *testing.T) {
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	d, bus, cmd := startDispatcher(t, []Hook{{Name: "bot", URL: srv.URL, On: []Trigger{TriggerCrash, TriggerReadiness}}})
+
+	code := 1
+	bus.Publish(events.RunExited, cmd.ID, manager.Run{Number: 1, ExitCode: &code, Stopped: true})
+	bus.Publish(events.CommandReady, cmd.ID, nil)
+
+	delivery := waitForStatus(t, d, DeliveryDelivered)
+	assert.Equal(t, TriggerReadiness, delivery.Trigger)
+	assert.Len(t, d.Deliveries(), 1)
+	assert.Equal(t, 1, rc.count())
+}
+
+func TestDispatcher_RetriesWithBackoff(t *testing.T) {
+	rc := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	d, bus, cmd := startDispatcher(t, []Hook{{Name: "bot", URL: srv.URL, On: []Trigger{TriggerReadiness}}})
+	bus.Publish(events.CommandReady, cmd.ID, nil)
+
+	delivery := waitForStatus(t, d, DeliveryDelivered)
+	require.Len(t, delivery.Attempts, 3)
+	assert.Equal(t, http.StatusServiceUnavailable, delivery.Attempts[0].StatusCode)
+	assert.Equal(t, http.StatusTooManyRequests, delivery.Attempts[1].StatusCode)
+	assert.Equal(t, http.StatusOK, delivery.Attempts[2].StatusCode)
+	assert.GreaterOrEqual(t, delivery.Attempts[2].Time.Sub(delivery.Attempts[1].Time), 20*time.Millisecond, "backoff doubles")
+}
+
+func TestDispatcher_GivesUp(t *testing.T) {
+	t.Run("after the last attempt", func(t *testing.T) {
+		rc := &receiver{statuses: []int{500, 500, 500, 500}}
+		srv := httptest.NewServer(rc)
+		defer srv.Close()
+
+		d, bus, cmd := startDispatcher(t, []Hook{{Name: "bot", URL: srv.URL, On: []Trigger{TriggerReadiness}}})
+		bus.Publish(events.CommandReady, cmd.ID, nil)
+
+		delivery := waitForStatus(t, d, DeliveryFailed)
+		assert.Len(t, delivery.Attempts, 3)
+		assert.Equal(t, "500 Internal Server Error", delivery.Attempts[2].Error)
+	})
+
+	t.Run("on a client error", func(t *testing.T) {
+		rc := &receiver{statuses: []int{http.StatusNotFound}}
+		srv := httptest.NewServer(rc)
+		defer srv.Close()
+
+		d, bus, cmd := startDispatcher(t, []Hook{{Name: "bot", URL: srv.URL, On: []Trigger{TriggerReadiness}}})
+		bus.Publish(events.CommandReady, cmd.ID, nil)
+
+		delivery := waitForStatus(t, d, DeliveryFailed)
+		assert.Len(t, delivery.Attempts, 1)
+	})
+
+	t.Run("when unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		url := srv.URL + "/hooks/T000/secret-token"
+		srv.Close()
+
+		d, bus, _ := startDispatcher(t, []Hook{{Name: "bot", URL: url, On: []Trigger{TriggerReadiness}}})
+		bus.Publish(events.CommandReady, uuid.New(), nil)
+
+		delivery := waitForStatus(t, d, DeliveryFailed)
+		assert.Len(t, delivery.Attempts, 3)
+		assert.NotEmpty(t, delivery.Attempts[0].Error)
+		assert.NotContains(t, delivery.Attempts[0].Error, "secret-token")
+	})
+}
+
+func TestDispatcher_HooksOmitSecrets(t *testing.T) {
+	d := New([]Hook{{Name: "bot", URL: "https://hooks.example.com/services/T000/XXXX", Secret: "hush", On: []Trigger{TriggerCrash}}}, nil)
+	assert.Empty(t, d.Hooks()[0].Secret)
+	assert.Equal(t, "https://hooks.example.com/[REDACTED]", d.Hooks()[0].URL)
+	assert.Equal(t, "hush", d.hooks[0].Secret)
+	assert.Equal(t, "https://hooks.example.com/services/T000/XXXX", d.hooks[0].URL)
+}
+
+func TestMaskURL(t *testing.T) {
+	tests := map[string]string{
+		"https://chat.example.com":               "https://chat.example.com",
+		"https://chat.example.com/":              "https://chat.example.com",
+		"https://chat.example.com/hook/abc":      "https://chat.example.com/[REDACTED]",
+		"https://chat.example.com?token=abc":     "https://chat.example.com/[REDACTED]",
+		"https://user:pw@chat.example.com:8443/": "https://chat.example.com:8443/[REDACTED]",
+		"not a url":                              "[REDACTED]",
+	}
+	for raw, want := range tests {
+		assert.Equal(t, want, MaskURL(raw), raw)
+	}
+}
@@ -0,0 +1,137 @@
+// Package webhook posts lifecycle events of interest to configured URLs.
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/google/uuid"
+)
+
+var ErrInvalidHook = errors.New("invalid webhook")
+
+// Trigger is a condition a hook fires on.
+type Trigger string
+
+const (
+	// TriggerCrash fires when a run ends with a non-zero exit code or fails
+	// to run, unless it is reported as a test failure instead.
+	TriggerCrash Trigger = "crash"
+	// TriggerTestFailure fires when a run ends with failed tests.
+	TriggerTestFailure Trigger = "test_failure"
+	// TriggerReadiness fires when a command becomes ready or unhealthy.
+	TriggerReadiness Trigger = "readiness"
+)
+
+// Hook is one webhook endpoint.
+type Hook struct {
+	// Name identifies the hook in the delivery log; the URL is kept out of
+	// it since chat services embed credentials in theirs.
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Secret, if set, signs each request; see Signature.
+	Secret string    `json:"secret,omitempty"`
+	On     []Trigger `json:"on"`
+}
+
+func (h Hook) validate() error {
+	if h.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidHook)
+	}
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %s: url must be an absolute http or https URL", ErrInvalidHook, h.Name)
+	}
+	if len(h.On) == 0 {
+		return fmt.Errorf("%w: %s: on is required", ErrInvalidHook, h.Name)
+	}
+	for _, t := range h.On {
+		switch t {
+		case TriggerCrash, TriggerTestFailure, TriggerReadiness:
+		default:
+			return fmt.Errorf("%w: %s: unknown trigger %q", ErrInvalidHook, h.Name, t)
+		}
+	}
+	return nil
+}
+
+// Validate checks hooks for missing fields, bad URLs, unknown triggers and
+// duplicate names.
+func Validate(hooks []Hook) error {
+	seen := make(map[string]bool, len(hooks))
+	for _, h := range hooks {
+		if err := h.validate(); err != nil {
+			return err
+		}
+		if seen[h.Name] {
+			return fmt.Errorf("%w: duplicate name %q", ErrInvalidHook, h.Name)
+		}
+		seen[h.Name] = true
+	}
+	return nil
+}
+
+// LoadConfig reads the webhooks section of the config file at path.
+func LoadConfig(path string) ([]Hook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Webhooks []Hook `json:"webhooks"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidHook, path, err)
+	}
+	if err := Validate(cfg.Webhooks); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg.Webhooks, nil
+}
+
+// Payload is the JSON body posted to a hook.
+type Payload struct {
+	Delivery string    `json:"delivery"`
+	Trigger  Trigger   `json:"trigger"`
+	Time     time.Time `json:"time"`
+	Command  struct {
+		ID   uuid.UUID `json:"id"`
+		Name string    `json:"name,omitempty"`
+	} `json:"command"`
+	// Run is set for crash and test_failure.
+	Run *manager.Run `json:"run,omitempty"`
+	// Ready is set for readiness.
+	Ready *bool `json:"ready,omitempty"`
+}
+
+// classify maps an event to the trigger it represents, if any.
+func classify(e events.Event) (Trigger, bool) {
+	switch e.Type {
+	case events.CommandReady, events.CommandUnhealthy:
+		return TriggerReadiness, true
+	case events.RunExited:
+		run, ok := e.Data.(manager.Run)
+		// A stopped run may exit non-zero from its signal handler.
+		if !ok || run.Stopped {
+			return "", false
+		}
+		if run.Tests != nil && run.Tests.Failed > 0 {
+			return TriggerTestFailure, true
+		}
+		if (run.ExitCode != nil && *run.ExitCode != 0) || run.Error != "" {
+			return TriggerCrash, true
+		}
+	}
+	return "", false
+}
+
+func (h Hook) wants(t Trigger) bool {
+	return slices.Contains(h.On, t)
+}
@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cloud-gt/ai-sensors/events"
+	"github.com/cloud-gt/ai-sensors/manager"
+	"github.com/cloud-gt/ai-sensors/testreport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	valid := Hook{Name: "bot", URL: "https://chat.example.com/hook", On: []Trigger{TriggerCrash}}
+	require.NoError(t, Validate([]Hook{valid}))
+
+	tests := map[string]Hook{
+		"no name":         {URL: valid.URL, On: valid.On},
+		"relative url":    {Name: "x", URL: "/hook", On: valid.On},
+		"other scheme":    {Name: "x", URL: "ftp://example.com", On: valid.On},
+		"no triggers":     {Name: "x", URL: valid.URL},
+		"unknown trigger": {Name: "x", URL: valid.URL, On: []Trigger{"boom"}},
+	}
+	for name, h := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.ErrorIs(t, Validate([]Hook{h}), ErrInvalidHook)
+		})
+	}
+
+	assert.ErrorIs(t, Validate([]Hook{valid, valid}), ErrInvalidHook, "duplicate names")
+}
+
+func TestLoadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"policy": {"read_only": true},
+		"webhooks": [{"name": "bot", "url": "http://localhost:9000", "secret": "s", "on": ["crash", "readiness"]}]
+	}`), 0o600))
+
+	hooks, err := LoadConfig(path)
+	require.NoError(t, err)
+	require.Len(t, hooks, 1)
+	assert.Equal(t, "s", hooks[0].Secret)
+	assert.Equal(t, []Trigger{TriggerCrash, TriggerReadiness}, hooks[0].On)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"webhooks": [{"name": "bot"}]}`), 0o600))
+	_, err = LoadConfig(path)
+	assert.ErrorIs(t, err, ErrInvalidHook)
+}
+
+func TestClassify(t *testing.T) {
+	zero, one := 0, 1
+	tests := []struct {
+		name  string
+		event events.Event
+		want  Trigger
+	}{
+		{"clean exit", events.Event{Type: events.RunExited, Data: manager.Run{ExitCode: &zero}}, ""},
+		{"stopped", events.Event{Type: events.RunExited, Data: manager.Run{}}, ""},
+		{"non-zero exit", events.Event{Type: events.RunExited, Data: manager.Run{ExitCode: &one}}, TriggerCrash},
+		{"non-zero exit after stop", events.Event{Type: events.RunExited, Data: manager.Run{ExitCode: &one, Stopped: true}}, ""},
+		{"failed to run", events.Event{Type: events.RunExited, Data: manager.Run{Error: "timeout"}}, TriggerCrash},
+		{"failed tests", events.Event{Type: events.RunExited, Data: manager.Run{
+			ExitCode: &one, Tests: &testreport.Summary{Total: 2, Failed: 1},
+		}}, TriggerTestFailure},
+		{"ready", events.Event{Type: events.CommandReady}, TriggerReadiness},
+		{"unhealthy", events.Event{Type: events.CommandUnhealthy}, TriggerReadiness},
+		{"other", events.Event{Type: events.RunStarted, Data: manager.Run{}}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := classify(tt.event)
+			assert.Equal(t, tt.want != "", ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}